@@ -0,0 +1,310 @@
+package typewriter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"os"
+	"unicode"
+)
+
+// AnnotationFile is the default name of an optional file, in the package directory, which annotates types
+// as an alternative to directive comments. It maps directives to type names to tags, eg:
+//
+//	{
+//		"+gen": {
+//			"Thing": {
+//				"pointer": true,
+//				"tags": {
+//					"slice": "Where,SortBy",
+//					"set": ""
+//				}
+//			}
+//		}
+//	}
+//
+// Tag values are written as they would be in a comment. Annotations in the file are merged with those in comments;
+// a tag declared in both places is an error. See also Config.AnnotationFile.
+const AnnotationFile = "typewriter.json"
+
+// annotation is a parsed directive for a type, from a comment or an annotation file
+type annotation struct {
+	pointer Pointer
+	// whether the pointer is declared; comments always declare it, files only if they have the key
+	declared bool
+	tags     TagSlice
+	pos      token.Pos
+	tagPos   map[string]token.Pos // for error reporting
+}
+
+// merge adds the tags of another annotation of the same type, reporting conflicts at both positions
+func (an *annotation) merge(fset *token.FileSet, name string, other *annotation) error {
+	if other.declared {
+		if an.declared && an.pointer != other.pointer {
+			return newError(fset.Position(other.pos), CodeConflict, "pointer declaration on %s conflicts with %s", name, fset.Position(an.pos))
+		}
+		an.pointer, an.declared = other.pointer, true
+	}
+
+	for _, tag := range other.tags {
+		if _, found := findTag(an.tags, tag.Name); found {
+			err := newError(fset.Position(other.tagPos[tag.Name]), CodeConflict, "tag %q on %s conflicts with %s", tag.Name, name, fset.Position(an.tagPos[tag.Name]))
+			err.Tag = tag.Name
+			return err
+		}
+		an.tags = append(an.tags, tag)
+		an.tagPos[tag.Name] = other.tagPos[tag.Name]
+	}
+
+	return nil
+}
+
+// newCommentAnnotation parses a directive comment into an annotation
+func newCommentAnnotation(fset *token.FileSet, c *ast.Comment, directive string) (*annotation, error) {
+	pointer, tags, err := parse(fset, c, directive)
+
+	if err != nil {
+		return nil, err
+	}
+
+	an := &annotation{
+		pointer:  pointer,
+		declared: true,
+		tags:     tags,
+		pos:      c.Slash,
+		tagPos:   make(map[string]token.Pos),
+	}
+
+	for _, tag := range tags {
+		an.tagPos[tag.Name] = c.Slash
+	}
+
+	return an, nil
+}
+
+// readAnnotationFile reads the annotations for the directives from an annotation file, keyed by directive and
+// type name. The file is read, and its errors reported, once for all directives. A missing file is not an error.
+func readAnnotationFile(fset *token.FileSet, filename string, directives []string) (map[string]map[string]*annotation, error) {
+	result := make(map[string]map[string]*annotation)
+
+	data, err := os.ReadFile(filename)
+
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+
+	if err != nil {
+		return result, err
+	}
+
+	// add the file to the fset, so errors can point to it
+	file := fset.AddFile(filename, -1, len(data))
+	file.SetLinesForContent(data)
+
+	r := &annotationReader{
+		dec:  json.NewDecoder(bytes.NewReader(data)),
+		data: data,
+		file: file,
+		fset: fset,
+	}
+
+	wanted := make(map[string]bool)
+	for _, d := range directives {
+		wanted[d] = true
+	}
+
+	err = r.object(func(d string, _ token.Pos) error {
+		if !wanted[d] {
+			// not a directive we care about; skip it
+			return r.skip()
+		}
+
+		annotations := result[d]
+		if annotations == nil {
+			annotations = make(map[string]*annotation)
+			result[d] = annotations
+		}
+		r.directive = d
+
+		return r.object(func(name string, pos token.Pos) error {
+			if _, found := annotations[name]; found {
+				return r.errorf(pos, "duplicate type %q", name)
+			}
+
+			an, err := r.annotation(pos)
+			if err != nil {
+				return err
+			}
+
+			annotations[name] = an
+			return nil
+		})
+	})
+
+	return result, err
+}
+
+type annotationReader struct {
+	dec       *json.Decoder
+	data      []byte
+	file      *token.File
+	fset      *token.FileSet
+	directive string // of the object being read
+}
+
+func (r *annotationReader) errorf(pos token.Pos, format string, args ...interface{}) error {
+	return newError(r.fset.Position(pos), CodeAnnotationFile, format, args...)
+}
+
+// pos returns the position of the next token in the input, following any whitespace, colon or comma
+func (r *annotationReader) pos() token.Pos {
+	offset := int(r.dec.InputOffset())
+	for offset < len(r.data) && (unicode.IsSpace(rune(r.data[offset])) || r.data[offset] == ':' || r.data[offset] == ',') {
+		offset++
+	}
+	if offset >= len(r.data) {
+		offset = len(r.data) - 1
+	}
+	return r.file.Pos(offset)
+}
+
+// token reads the next token, translating syntax errors into positioned errors
+func (r *annotationReader) token() (json.Token, token.Pos, error) {
+	pos := r.pos()
+	t, err := r.dec.Token()
+
+	if err == io.EOF {
+		return nil, pos, r.errorf(pos, "unexpected end of file")
+	}
+
+	if err != nil {
+		return nil, pos, r.errorf(pos, "%s", err)
+	}
+
+	return t, pos, nil
+}
+
+// object reads a JSON object, calling fn for each key; fn must consume the value
+func (r *annotationReader) object(fn func(key string, pos token.Pos) error) error {
+	t, pos, err := r.token()
+	if err != nil {
+		return err
+	}
+
+	if t != json.Delim('{') {
+		return r.errorf(pos, "expected an object")
+	}
+
+	for r.dec.More() {
+		t, pos, err := r.token()
+		if err != nil {
+			return err
+		}
+
+		if err := fn(t.(string), pos); err != nil {
+			return err
+		}
+	}
+
+	// absorb the close brace
+	_, _, err = r.token()
+	return err
+}
+
+// skip reads and discards the next value
+func (r *annotationReader) skip() error {
+	var v interface{}
+	if err := r.dec.Decode(&v); err != nil {
+		return r.errorf(r.pos(), "%s", err)
+	}
+	return nil
+}
+
+// annotation reads the annotation of a single type
+func (r *annotationReader) annotation(pos token.Pos) (*annotation, error) {
+	an := &annotation{
+		pos:    pos,
+		tagPos: make(map[string]token.Pos),
+	}
+
+	err := r.object(func(key string, pos token.Pos) error {
+		switch key {
+		case "pointer":
+			t, pos, err := r.token()
+			if err != nil {
+				return err
+			}
+
+			b, ok := t.(bool)
+			if !ok {
+				return r.errorf(pos, "pointer must be true or false")
+			}
+
+			an.pointer, an.declared = Pointer(b), true
+			return nil
+		case "tags":
+			return r.object(func(name string, pos token.Pos) error {
+				t, valPos, err := r.token()
+				if err != nil {
+					return err
+				}
+
+				vals, ok := t.(string)
+				if !ok {
+					return r.errorf(valPos, "values of tag %q must be a string", name)
+				}
+
+				tag, err := r.tag(name, vals, valPos)
+				if err != nil {
+					return err
+				}
+
+				if _, found := findTag(an.tags, name); found {
+					return r.errorf(pos, "duplicate tag %q", name)
+				}
+
+				an.tags = append(an.tags, tag)
+				an.tagPos[name] = pos
+				return nil
+			})
+		default:
+			return r.errorf(pos, "unknown key %q, expected pointer or tags", key)
+		}
+	})
+
+	return an, err
+}
+
+// tag parses a tag and its values, as they would be written in a comment
+func (r *annotationReader) tag(name, vals string, pos token.Pos) (Tag, error) {
+	text := directivePrefix(r.directive) + name
+	if len(vals) > 0 {
+		text += ":"
+	}
+
+	// position the comment such that its values line up with the values in the file,
+	// taking the open quote into account
+	slash := pos - token.Pos(len(text))
+	if slash < token.Pos(r.file.Base()) {
+		slash = token.Pos(r.file.Base())
+	}
+
+	if len(vals) > 0 {
+		text += fmt.Sprintf(`"%s"`, vals)
+	}
+
+	_, tags, err := parse(r.fset, &ast.Comment{Slash: slash, Text: text}, r.directive)
+
+	if err != nil {
+		return Tag{}, err
+	}
+
+	if len(tags) != 1 {
+		return Tag{}, r.errorf(pos, "invalid tag %q", name)
+	}
+
+	return tags[0], nil
+}
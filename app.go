@@ -25,6 +25,14 @@ type App struct {
 	// All typewriter.Interface's registered on init.
 	TypeWriters []Interface
 	Directive   string
+	// Directives binds each directive to its typewriters, where the App was created by NewAppDirectives.
+	Directives []Directive
+}
+
+// Directive binds a directive, such as +gen, to the typewriters which write code for the declarations it annotates.
+type Directive struct {
+	Name        string
+	TypeWriters []Interface
 }
 
 // NewApp parses the current directory, enumerating registered TypeWriters and collecting Types and their related information.
@@ -32,13 +40,63 @@ func NewApp(directive string) (*App, error) {
 	return DefaultConfig.NewApp(directive)
 }
 
+// NewApp parses the current directory for comments annotated with the directive, which takes one of two forms:
+//
+//	+gen   written after a space, followed by a space, eg // +gen slice:"Where"
+//	gen:   written without a space, in the style of Go directives such as //go:generate, eg //gen:slice:"Where"
+//
+// Comments of the second form are left as they are by gofmt. Comments of either form are left out of the Doc of Types and Targets.
 func (conf *Config) NewApp(directive string) (*App, error) {
 	a := &App{
 		Directive:   directive,
 		TypeWriters: typeWriters,
 	}
 
-	pkgs, err := getPackages(directive, conf)
+	pkgs, err := getPackages([]string{directive}, conf)
+
+	a.Packages = pkgs
+	return a, err
+}
+
+// NewAppDirectives parses the current directory for several directives, each bound to its typewriters. See Config.NewAppDirectives.
+func NewAppDirectives(directives ...Directive) (*App, error) {
+	return DefaultConfig.NewAppDirectives(directives...)
+}
+
+// NewAppDirectives parses and type-checks the current directory once, collecting the Types and Targets annotated with each
+// of the directives. Code for each Type and Target is written by the typewriters bound to the directive which annotated it.
+func (conf *Config) NewAppDirectives(directives ...Directive) (*App, error) {
+	if len(directives) == 0 {
+		return nil, fmt.Errorf("at least one directive is required")
+	}
+
+	a := &App{
+		Directive:  directives[0].Name,
+		Directives: directives,
+	}
+
+	var names []string
+	for _, d := range directives {
+		for _, name := range names {
+			if d.Name == name {
+				return nil, fmt.Errorf("directive %s is bound more than once", d.Name)
+			}
+		}
+		names = append(names, d.Name)
+
+		// all typewriters, once each
+	Loop:
+		for _, tw := range d.TypeWriters {
+			for _, v := range a.TypeWriters {
+				if v.Name() == tw.Name() {
+					continue Loop
+				}
+			}
+			a.TypeWriters = append(a.TypeWriters, tw)
+		}
+	}
+
+	pkgs, err := getPackages(names, conf)
 
 	a.Packages = pkgs
 	return a, err
@@ -82,7 +140,7 @@ func (a *App) WriteAll() ([]string, error) {
 	// write the generated code for each Type & TypeWriter into memory
 	for _, p := range a.Packages {
 		for _, t := range p.Types {
-			for _, tw := range a.TypeWriters {
+			for _, tw := range a.typeWriters(t.Directive) {
 				var b bytes.Buffer
 				n, err := write(&b, a, p, t, tw)
 
@@ -95,9 +153,36 @@ func (a *App) WriteAll() ([]string, error) {
 					continue
 				}
 
-				// append _test to file name if the source type is in a _test.go file
-				f := strings.ToLower(fmt.Sprintf("%s_%s%s.go", t.Name, tw.Name(), t.test))
+				f := a.fileName(t.Name, t.Directive, tw, t.test)
+				if _, ok := buffers[f]; ok {
+					return written, newError(t.Position, CodeDuplicateFile, "%s would be written more than once, by %s on %s", f, tw.Name(), t.Name)
+				}
+				buffers[f] = &b
+			}
+		}
+
+		for _, t := range p.Targets {
+			for _, tw := range a.typeWriters(t.Directive) {
+				ttw, ok := tw.(TargetWriter)
+				if !ok {
+					continue
+				}
+
+				var b bytes.Buffer
+				n, err := writeTarget(&b, a, p, t, ttw)
+
+				if err != nil {
+					return written, err
+				}
+
+				if n == 0 {
+					continue
+				}
 
+				f := a.fileName(t.Name, t.Directive, tw, t.test)
+				if _, ok := buffers[f]; ok {
+					return written, newError(t.Position, CodeDuplicateFile, "%s would be written more than once, by %s on %s", f, tw.Name(), t.Name)
+				}
 				buffers[f] = &b
 			}
 		}
@@ -130,9 +215,94 @@ func (a *App) WriteAll() ([]string, error) {
 	return written, nil
 }
 
+// directive returns the directive which annotated a Type or Target, defaulting to the App's
+func (a *App) directive(directive string) string {
+	if directive == "" {
+		return a.Directive
+	}
+	return directive
+}
+
+// typeWriters returns the typewriters bound to the directive, or all of the App's typewriters if there is no binding
+func (a *App) typeWriters(directive string) []Interface {
+	for _, d := range a.Directives {
+		if d.Name == directive {
+			return d.TypeWriters
+		}
+	}
+	return a.TypeWriters
+}
+
 var twoLines = bytes.Repeat([]byte{'\n'}, 2)
 
+// fileName returns the name of the generated file for an annotated name and typewriter,
+// appending _test if the source declaration is in a _test.go file. Declarations annotated by
+// a directive other than the App's first are named for it, too, eg thing_other_foo.go, such
+// that two directives bound to the same typewriter don't write the same file
+func (a *App) fileName(name, directive string, tw Interface, t test) string {
+	name = strings.Replace(name, ".", "_", -1) // methods are named Receiver.Method
+	if directive != "" && directive != a.Directive {
+		name += "_" + directiveName(directive)
+	}
+	return strings.ToLower(fmt.Sprintf("%s_%s%s.go", name, tw.Name(), t))
+}
+
 func write(w *bytes.Buffer, a *App, p *Package, t Type, tw Interface) (n int, err error) {
+	// a type from another package (see target tag), or type parameters such as GroupBy[time.Time], require imports
+	plan := planImports(tw.Imports(t), t)
+
+	if err := writeHeader(w, a.directive(t.Directive), p, tw, t.String(), plan.Imports()); err != nil {
+		return n, err
+	}
+
+	c := countingWriter{0, w}
+	err = tw.Write(&c, t.withImports(plan))
+	n += c.n
+
+	return n, err
+}
+
+// planImports plans the imports of a typewriter, followed by those of the packages to which the type and the type
+// parameters of its tags refer, rather than leaving imports.Process to guess them by name. See ImportPlan.
+func planImports(imports []ImportSpec, t Type) *ImportPlan {
+	plan := NewImportPlan(imports...)
+
+	typs := []Type{t}
+	for _, tag := range t.Tags {
+		for _, v := range tag.Values {
+			typs = append(typs, v.TypeParameters...)
+		}
+	}
+
+	for _, typ := range typs {
+		for _, pkg := range typ.packages() {
+			imp := ImportSpec{Path: pkg.Path()}
+			if imp.name() != pkg.Name() {
+				imp.Name = pkg.Name()
+			}
+			plan.Add(imp)
+		}
+	}
+
+	return plan
+}
+
+func writeTarget(w *bytes.Buffer, a *App, p *Package, t Target, tw TargetWriter) (n int, err error) {
+	imports := NewImportPlan(tw.TargetImports(t)...).Imports()
+
+	if err := writeHeader(w, a.directive(t.Directive), p, tw, t.String(), imports); err != nil {
+		return n, err
+	}
+
+	c := countingWriter{0, w}
+	err = tw.WriteTarget(&c, t)
+	n += c.n
+
+	return n, err
+}
+
+// writeHeader writes the byline, package declaration and imports which precede generated code
+func writeHeader(w *bytes.Buffer, directive string, p *Package, tw Interface, subject string, imports []ImportSpec) error {
 	// start with byline at top, give future readers some background
 	// on where the file came from
 	bylineFmt := `// Generated by: %s
@@ -140,7 +310,7 @@ func write(w *bytes.Buffer, a *App, p *Package, t Type, tw Interface) (n int, er
 // Directive: %s on %s`
 
 	caller := filepath.Base(os.Args[0])
-	byline := fmt.Sprintf(bylineFmt, caller, tw.Name(), a.Directive, t.String())
+	byline := fmt.Sprintf(bylineFmt, caller, tw.Name(), directive, subject)
 	w.Write([]byte(byline))
 	w.Write(twoLines)
 
@@ -149,15 +319,7 @@ func write(w *bytes.Buffer, a *App, p *Package, t Type, tw Interface) (n int, er
 	w.Write([]byte(pkg))
 	w.Write(twoLines)
 
-	if err := importsTmpl.Execute(w, tw.Imports(t)); err != nil {
-		return n, err
-	}
-
-	c := countingWriter{0, w}
-	err = tw.Write(&c, t)
-	n += c.n
-
-	return n, err
+	return importsTmpl.Execute(w, imports)
 }
 
 func writeFile(filename string, byts []byte) error {
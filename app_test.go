@@ -3,6 +3,8 @@ package typewriter
 import (
 	"bytes"
 	"fmt"
+	"go/ast"
+	"go/token"
 	"io"
 	"os"
 	"strings"
@@ -63,6 +65,80 @@ func TestNewApp(t *testing.T) {
 	typeWriters = make([]Interface, 0)
 }
 
+func TestNewAppDirectives(t *testing.T) {
+	fw := &fooWriter{}
+	bw := &barWriter{}
+
+	a, err := NewAppDirectives(
+		Directive{"+test", []Interface{fw}},
+		Directive{"other:", []Interface{bw}},
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.TypeWriters) != 2 {
+		t.Errorf("should have found 2 typewriters, found %v", len(a.TypeWriters))
+	}
+
+	counts := make(map[string]int)
+	for _, typ := range a.Packages[0].Types {
+		counts[typ.Directive]++
+	}
+
+	if counts["+test"] != 4 || counts["other:"] != 1 {
+		t.Errorf("should have found 4 types for +test and 1 for other:, found %v", counts)
+	}
+
+	written, err := a.WriteAll()
+	cleanup(written) // we don't need the written files
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if fw.writeCalls != 4 || bw.writeCalls != 1 {
+		t.Errorf("foo should have written 4 types and bar 1, wrote %v and %v", fw.writeCalls, bw.writeCalls)
+	}
+
+	// dummy3 is annotated by both directives; binding the same typewriter to both should write two files
+	shared := &fooWriter{}
+
+	a, err = NewAppDirectives(
+		Directive{"+test", []Interface{shared}},
+		Directive{"other:", []Interface{shared}},
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written, err = a.WriteAll()
+	cleanup(written) // we don't need the written files
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	files := make(map[string]bool)
+	for _, f := range written {
+		files[f] = true
+	}
+
+	if !files["dummy3_foo_test.go"] || !files["dummy3_other_foo_test.go"] {
+		t.Errorf("should have written a file for each directive on dummy3, got %v", written)
+	}
+
+	if _, err := NewAppDirectives(Directive{"+test", nil}, Directive{"+test", nil}); err == nil {
+		t.Errorf("binding a directive twice should be an error")
+	}
+
+	if _, err := NewAppDirectives(); err == nil {
+		t.Errorf("no directives should be an error")
+	}
+}
+
 func TestNewAppFiltered(t *testing.T) {
 	filter := func(f os.FileInfo) bool {
 		return !strings.HasPrefix(f.Name(), "dummy")
@@ -126,6 +202,135 @@ func TestWrite(t *testing.T) {
 	}
 }
 
+func TestWriteForeign(t *testing.T) {
+	a := &App{
+		Directive: "+test",
+	}
+
+	p := NewPackage("dummy", "somepkg")
+
+	typ, err := p.evalTarget(false, Tag{Name: "target", Values: []TagValue{{Name: "go/token.Position"}}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	write(&b, a, p, typ, &barWriter{})
+
+	if !strings.Contains(b.String(), `"go/token"`) {
+		t.Errorf("import of the foreign type's package did not get written")
+	}
+}
+
+func TestWriteTypeParameterImports(t *testing.T) {
+	a := &App{
+		Directive: "+test",
+	}
+
+	// type parameters refer to packages by the names under which the file imports them, or by path
+	p, f := testPackage(t, `package somepkg
+
+import (
+	"text/template"
+	tm "time"
+)
+
+// +test foo:"GroupBy[tm.Duration],Execute[template.Template,go/token.Pos],Read[io.Reader]"
+type sometype struct{}
+
+var _ template.Template
+var _ tm.Duration
+`)
+
+	pkg := &ast.Package{
+		Name:  f.Name.Name,
+		Files: map[string]*ast.File{"test.go": f},
+	}
+
+	var errs ErrorList
+	p.annotate(pkg, "+test", nil, &errs, func(error, token.Pos) bool { return false })
+
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	typ := p.Types[0]
+
+	var names []string
+	for _, v := range typ.Tags[0].Values {
+		for _, tp := range v.TypeParameters {
+			names = append(names, tp.String())
+		}
+	}
+
+	// as written, or named for the package when referred to by path
+	if strings.Join(names, ",") != "tm.Duration,template.Template,token.Pos,io.Reader" {
+		t.Errorf("type parameters should have been evaluated in the scope of the file, got %v", names)
+	}
+
+	if _, err := p.evalIn(typ.Spec.Pos(), "github.com/x/nosuch.User"); err == nil {
+		t.Errorf("a package which can't be imported should be an error")
+	}
+
+	imports := planImports((&htmlWriter{}).Imports(typ), typ).Imports()
+
+	expected := []ImportSpec{
+		{Path: "html/template"},
+		{Path: "time"},
+		{Name: "template2", Path: "text/template"},
+		{Path: "go/token"},
+		{Path: "io"},
+	}
+
+	if len(imports) != len(expected) {
+		t.Fatalf("imports should be %v, got %v", expected, imports)
+	}
+
+	for i, imp := range expected {
+		if imports[i] != imp {
+			t.Errorf("import %v should be %v, got %v", i, imp, imports[i])
+		}
+	}
+
+	var b bytes.Buffer
+	write(&b, a, p, typ, &htmlWriter{})
+
+	s := b.String()
+
+	if !strings.Contains(s, `"go/token"`) || !strings.Contains(s, `template2 "text/template"`) {
+		t.Errorf("imports of the type parameters' packages did not get written")
+	}
+
+	// the typewriter refers to the types by the names under which they are imported
+	if !strings.Contains(s, "var _ template2.Template // template2") {
+		t.Errorf("type parameter should have been qualified by its alias, got %s", s)
+	}
+
+	// as are those which templates render with String, whatever name the annotated file imports them under
+	if !strings.Contains(s, "var _ time.Duration") {
+		t.Errorf("type parameter should have been named for the planned import, got %s", s)
+	}
+
+	if typ.Tags[0].Values[1].TypeParameters[0].Expr() != "template.Template" || typ.Tags[0].Values[0].TypeParameters[0].String() != "tm.Duration" {
+		t.Errorf("the imports of the written file should not leak into the type")
+	}
+}
+
+type htmlWriter struct {
+	barWriter
+}
+
+func (f *htmlWriter) Imports(t Type) []ImportSpec {
+	return []ImportSpec{{Path: "html/template"}}
+}
+
+func (f *htmlWriter) Write(w io.Writer, t Type) error {
+	tp := t.Tags[0].Values[1].TypeParameters[0]
+	_, err := fmt.Fprintf(w, "var _ %s // %s\nvar _ %s\n", tp.Expr(), t.Qualifier("text/template"), t.Tags[0].Values[0].TypeParameters[0])
+	return err
+}
+
 func cleanup(files []string) {
 	for _, f := range files {
 		os.Remove(f)
@@ -222,6 +427,45 @@ func TestWriteAll(t *testing.T) {
 	typeWriters = make([]Interface, 0)
 }
 
+func TestWriteAllTargets(t *testing.T) {
+	tw := &targetWriter{}
+	Register(tw)
+
+	a, err := NewApp("+test")
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	written, err := a.WriteAll()
+	cleanup(written) // we don't need the written files
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	targets := a.Packages[0].Targets
+
+	if tw.writeTargetCalls != len(targets) {
+		t.Errorf(".WriteTarget() should have been called %v times (once for each target); was called %v", len(targets), tw.writeTargetCalls)
+	}
+
+	found := false
+	for _, f := range written {
+		if f == "dummy_dummymethod_target_test.go" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("should have written a file for dummy.dummyMethod, got %v", written)
+	}
+
+	// clear 'em out for later tests
+	typeWriters = make([]Interface, 0)
+}
+
 type fooWriter struct {
 	writeCalls int
 }
@@ -294,3 +538,29 @@ func (f *junkWriter) Write(w io.Writer, t Type) error {
 	w.Write([]byte("this is invalid Go code, innit?"))
 	return nil
 }
+
+type targetWriter struct {
+	writeTargetCalls int
+}
+
+func (f *targetWriter) Name() string {
+	return "target"
+}
+
+func (f *targetWriter) Imports(t Type) (result []ImportSpec) {
+	return result
+}
+
+func (f *targetWriter) Write(w io.Writer, t Type) error {
+	return nil
+}
+
+func (f *targetWriter) TargetImports(t Target) (result []ImportSpec) {
+	return result
+}
+
+func (f *targetWriter) WriteTarget(w io.Writer, t Target) error {
+	f.writeTargetCalls++
+	w.Write([]byte(fmt.Sprintf("var _ = %q", t.Kind)))
+	return nil
+}
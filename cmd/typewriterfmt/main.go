@@ -0,0 +1,89 @@
+// Command typewriterfmt rewrites the directive comments of a package into canonical form, in the manner of gofmt:
+// tags are sorted by name, and written with consistent spacing.
+//
+// Usage:
+//
+//	typewriterfmt [-directive +gen] [-l] [dir ...]
+//
+// It formats the package in each directory, or in the current directory if none is given. Several directives may be
+// given, separated by commas, eg -directive +gen,mock:. With -l, it lists the files which would be rewritten, and
+// leaves them as they are.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/clipperhouse/typewriter"
+)
+
+var (
+	directives = flag.String("directive", "+gen", "directives to format, separated by commas")
+	list       = flag.Bool("l", false, "list files whose directives are not in canonical form, without rewriting them")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: typewriterfmt [-directive +gen] [-l] [dir ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	ok := true
+
+	for _, dir := range dirs {
+		if !format(dir, strings.Split(*directives, ",")) {
+			ok = false
+		}
+	}
+
+	if !ok {
+		os.Exit(2)
+	}
+}
+
+// format formats the directives of the package in dir, printing the names of the files which are (or would be)
+// rewritten, and any errors. It reports whether there were no errors.
+func format(dir string, directives []string) bool {
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	defer os.Chdir(wd)
+
+	ok := true
+
+	for _, directive := range directives {
+		var files []string
+		if *list {
+			files, err = typewriter.UnformattedDirectives(directive)
+		} else {
+			files, err = typewriter.FormatDirectives(directive)
+		}
+
+		for _, f := range files {
+			fmt.Println(filepath.Join(dir, f))
+		}
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			ok = false
+		}
+	}
+
+	return ok
+}
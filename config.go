@@ -5,6 +5,18 @@ import "os"
 type Config struct {
 	Filter                func(os.FileInfo) bool
 	IgnoreTypeCheckErrors bool
+	// AnnotationFile is the name of the optional file of annotations in the package directory; defaults to AnnotationFile
+	AnnotationFile string
 }
 
 var DefaultConfig = &Config{}
+
+// filter wraps the Filter with the default filter
+func (conf *Config) filter() func(os.FileInfo) bool {
+	return func(f os.FileInfo) bool {
+		if conf.Filter != nil {
+			return ignored(f) && conf.Filter(f)
+		}
+		return ignored(f)
+	}
+}
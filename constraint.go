@@ -1,6 +1,15 @@
 package typewriter
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"strings"
+)
 
 // Constraint describes type requirements.
 type Constraint struct {
@@ -10,6 +19,47 @@ type Constraint struct {
 	Comparable bool
 	// An ordered type is one where greater-than and less-than are supported
 	Ordered bool
+	// The kind of the underlying type, if basic. Signed and Unsigned are integers; Float is a floating-point number.
+	Integer, Float, Signed, Unsigned, String, Boolean bool
+	// The kind of the underlying type, otherwise.
+	Struct, Slice, Map, Pointer, Interface, Channel, Func bool
+	// An interface which the type must implement, by import path and name, eg fmt.Stringer or encoding/json.Marshaler.
+	// A name without a path is an interface of the type's package, or a predeclared interface such as error.
+	Implements string
+	// A method which the type must have, as it would be written in an interface, eg String() string. T refers to the
+	// type itself, eg Less(T) bool. Packages are referred to by name, as imported by the type's package or by path.
+	Method string
+	// A Go constraint which the type must satisfy, as it would be written in a type parameter list, eg comparable,
+	// cmp.Ordered or ~int | ~string. Packages and T are referred to as for Method.
+	Satisfies string
+	// Compositions of constraints: the type must also satisfy all of All, at least one of Any, and not Not.
+	// For example, a template for types which are ordered or have a Less method:
+	//	Constraint{Any: []Constraint{{Ordered: true}, {Method: "Less(T) bool"}}}
+	All, Any []Constraint
+	Not      *Constraint
+}
+
+// invalidConstraint is an error in the constraint itself, rather than a type which does not satisfy it
+type invalidConstraint struct {
+	err error
+}
+
+func (e invalidConstraint) Error() string {
+	return e.err.Error()
+}
+
+// kinds returns the kinds required by the constraint
+func (c Constraint) kinds() kinds {
+	// in the order of the kind constants
+	flags := []bool{c.Integer, c.Float, c.Signed, c.Unsigned, c.String, c.Boolean, c.Struct, c.Slice, c.Map, c.Pointer, c.Interface, c.Channel, c.Func}
+
+	var k kinds
+	for i, f := range flags {
+		if f {
+			k |= 1 << uint(i)
+		}
+	}
+	return k
 }
 
 func (c Constraint) TryType(t Type) error {
@@ -25,5 +75,365 @@ func (c Constraint) TryType(t Type) error {
 		return fmt.Errorf("%s must be ordered (i.e. support > and <)", t)
 	}
 
+	if c.Implements != "" {
+		iface, err := t.source.lookupInterface(c.Implements)
+		if err != nil {
+			return invalidConstraint{err}
+		}
+
+		if !implements(t, iface) {
+			return fmt.Errorf("%s must implement %s%s", t, c.Implements, missingMethod(t, iface))
+		}
+	}
+
+	if c.Method != "" {
+		iface, err := t.source.evalMethod(t, c.Method)
+		if err != nil {
+			return invalidConstraint{err}
+		}
+
+		if !implements(t, iface) {
+			return fmt.Errorf("%s must have method %s%s", t, c.Method, missingMethod(t, iface))
+		}
+	}
+
+	if c.Satisfies != "" {
+		iface, err := t.source.evalConstraint(t, c.Satisfies)
+		if err != nil {
+			return invalidConstraint{err}
+		}
+
+		if !types.Satisfies(t.Type, iface) {
+			return fmt.Errorf("%s does not satisfy %s%s", t, c.Satisfies, unsatisfied(t, iface))
+		}
+	}
+
+	required := c.kinds()
+	for k := kindInteger; k <= kindFunc; k <<= 1 {
+		if required&k != 0 && t.kinds&k == 0 {
+			return fmt.Errorf("%s must be %s%s", t, k, underlying(t))
+		}
+	}
+
+	for _, all := range c.All {
+		if err := all.TryType(t); err != nil {
+			return err
+		}
+	}
+
+	if len(c.Any) > 0 {
+		var errs []string
+
+		for _, alt := range c.Any {
+			err := alt.TryType(t)
+
+			if err == nil {
+				errs = nil
+				break
+			}
+
+			if _, ok := err.(invalidConstraint); ok {
+				return err
+			}
+
+			errs = append(errs, err.Error())
+		}
+
+		if len(errs) > 0 {
+			return fmt.Errorf("%s satisfies no alternative: %s", t, strings.Join(errs, ", or "))
+		}
+	}
+
+	if c.Not != nil {
+		err := c.Not.TryType(t)
+
+		if _, ok := err.(invalidConstraint); ok {
+			return err
+		}
+
+		if err == nil {
+			return fmt.Errorf("%s must not %s", t, c.Not.describe())
+		}
+	}
+
 	return nil
 }
+
+// describe returns the requirements of the constraint, as they would follow "must", eg be comparable and implement fmt.Stringer
+func (c Constraint) describe() string {
+	var reqs []string
+
+	if c.Comparable {
+		reqs = append(reqs, "be comparable")
+	}
+
+	if c.Numeric {
+		reqs = append(reqs, "be numeric")
+	}
+
+	if c.Ordered {
+		reqs = append(reqs, "be ordered")
+	}
+
+	if c.Implements != "" {
+		reqs = append(reqs, "implement "+c.Implements)
+	}
+
+	if c.Method != "" {
+		reqs = append(reqs, "have method "+c.Method)
+	}
+
+	if c.Satisfies != "" {
+		reqs = append(reqs, "satisfy "+c.Satisfies)
+	}
+
+	required := c.kinds()
+	for k := kindInteger; k <= kindFunc; k <<= 1 {
+		if required&k != 0 {
+			reqs = append(reqs, "be "+k.String())
+		}
+	}
+
+	for _, all := range c.All {
+		reqs = append(reqs, all.describe())
+	}
+
+	if len(c.Any) > 0 {
+		var alts []string
+		for _, alt := range c.Any {
+			alts = append(alts, alt.describe())
+		}
+		reqs = append(reqs, "("+strings.Join(alts, ", or ")+")")
+	}
+
+	if c.Not != nil {
+		reqs = append(reqs, "not "+c.Not.describe())
+	}
+
+	if len(reqs) == 0 {
+		return "satisfy an empty constraint"
+	}
+
+	return strings.Join(reqs, " and ")
+}
+
+// underlying describes the underlying type of t for error messages, if it is known and differs from t
+func underlying(t Type) string {
+	if t.Type == nil || t.Underlying() == t.Type {
+		return ""
+	}
+	return fmt.Sprintf(" (its underlying type is %s)", t.Underlying())
+}
+
+// unsatisfied describes why the type does not satisfy the constraint interface
+func unsatisfied(t Type, iface *types.Interface) string {
+	if iface.IsComparable() && !types.Comparable(t.Type) {
+		return " (it is not comparable)"
+	}
+
+	if m, wrongType := types.MissingMethod(t.Type, iface, true); m != nil {
+		if wrongType {
+			return fmt.Sprintf(" (wrong type for method %s)", m.Name())
+		}
+		return fmt.Sprintf(" (missing method %s)", m.Name())
+	}
+
+	if !iface.IsMethodSet() {
+		return fmt.Sprintf(" (%s is not in the type set)", t.Type)
+	}
+
+	return ""
+}
+
+// implements reports whether the type, or a pointer to it, implements the interface
+func implements(t Type, iface *types.Interface) bool {
+	if t.Type == nil {
+		return false
+	}
+
+	if types.Implements(t.Type, iface) {
+		return true
+	}
+
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Interface:
+		return false
+	}
+
+	return types.Implements(types.NewPointer(t.Type), iface)
+}
+
+// missingMethod describes why the type does not implement the interface, considering pointer methods
+func missingMethod(t Type, iface *types.Interface) string {
+	if t.Type == nil {
+		return ""
+	}
+
+	typ := t.Type
+	if _, ok := typ.Underlying().(*types.Pointer); !ok {
+		typ = types.NewPointer(typ)
+	}
+
+	m, wrongType := types.MissingMethod(typ, iface, true)
+
+	switch {
+	case m == nil:
+		return ""
+	case wrongType:
+		return fmt.Sprintf(" (wrong type for method %s)", m.Name())
+	default:
+		return fmt.Sprintf(" (missing method %s)", m.Name())
+	}
+}
+
+// lookupInterface returns the interface named by import path and name, eg encoding/json.Marshaler.
+// A name without a path is looked up in this package, then among predeclared types.
+func (p *Package) lookupInterface(name string) (*types.Interface, error) {
+	var obj types.Object
+
+	if i := strings.LastIndex(name, "."); i > strings.LastIndex(name, "/") {
+		pkg, err := p.importPackage(name[:i])
+		if err != nil {
+			return nil, err
+		}
+
+		obj = pkg.Scope().Lookup(name[i+1:])
+	} else {
+		if p != nil && p.Package != nil {
+			obj = p.Scope().Lookup(name)
+		}
+
+		if obj == nil {
+			obj = types.Universe.Lookup(name)
+		}
+	}
+
+	if obj, ok := obj.(*types.TypeName); ok {
+		if iface, ok := obj.Type().Underlying().(*types.Interface); ok {
+			return iface, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s is not an interface", name)
+}
+
+// evalMethod evaluates a method, as it would be written in an interface, into an interface with that method
+func (p *Package) evalMethod(t Type, method string) (*types.Interface, error) {
+	iface, err := p.evalInterface(t, fmt.Sprintf("interface{ %s }", method))
+	if err != nil {
+		return nil, fmt.Errorf("invalid method %q: %s", method, err)
+	}
+	return iface, nil
+}
+
+// evalConstraint evaluates a Go constraint expression, eg comparable, cmp.Ordered or ~int | ~string.
+// Expressions which do not refer to T are type-checked once per package, and cached.
+func (p *Package) evalConstraint(t Type, expr string) (*types.Interface, error) {
+	if t.Type == nil {
+		return nil, fmt.Errorf("%s has not been evaluated", t)
+	}
+
+	if p != nil {
+		if iface, ok := p.constraints[expr]; ok {
+			return iface, nil
+		}
+	}
+
+	// as in a type parameter list, a constraint may be a union of terms, eg ~int | ~string
+	iface, err := p.evalInterface(t, fmt.Sprintf("interface{ %s }", expr))
+	if err != nil {
+		return nil, fmt.Errorf("invalid constraint %q: %s", expr, err)
+	}
+
+	if p != nil && !refersToT(expr) {
+		if p.constraints == nil {
+			p.constraints = make(map[string]*types.Interface)
+		}
+		p.constraints[expr] = iface
+	}
+
+	return iface, nil
+}
+
+// refersToT reports whether the (valid) expression refers to the type T
+func refersToT(expr string) (found bool) {
+	x, _ := parser.ParseExpr(expr)
+	ast.Inspect(x, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == "T" {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// evalInterface evaluates an expression which must denote an interface.
+// It is evaluated in a scope where T is the type t, and packages are named as they are imported.
+func (p *Package) evalInterface(t Type, expr string) (*types.Interface, error) {
+	x, err := parser.ParseExpr(expr)
+	if list, ok := err.(scanner.ErrorList); ok && len(list) > 0 {
+		// positions are meaningless outside of the expression
+		return nil, errors.New(list[0].Msg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Type == nil {
+		return nil, fmt.Errorf("%s has not been evaluated", t)
+	}
+
+	scope := types.NewPackage("constraint", "constraint")
+	scope.Scope().Insert(types.NewTypeName(token.NoPos, scope, "T", t.Type))
+
+	// add the packages to which the expression refers, eg the io of io.Writer
+	ast.Inspect(x, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		id, ok := sel.X.(*ast.Ident)
+		if !ok || scope.Scope().Lookup(id.Name) != nil {
+			return true
+		}
+
+		if pkg, err := p.importPackageNamed(id.Name); err == nil {
+			scope.Scope().Insert(types.NewPkgName(token.NoPos, scope, id.Name, pkg))
+		}
+
+		return true
+	})
+
+	tv, err := types.Eval(token.NewFileSet(), scope, token.NoPos, expr)
+	if te, ok := err.(types.Error); ok {
+		return nil, errors.New(te.Msg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !tv.IsType() {
+		return nil, fmt.Errorf("%s is not a type", expr)
+	}
+
+	iface, ok := tv.Type.Underlying().(*types.Interface)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an interface", tv.Type)
+	}
+
+	return iface, nil
+}
+
+// importPackageNamed returns a package by the name under which it is imported by this package, or by path
+func (p *Package) importPackageNamed(name string) (*types.Package, error) {
+	if p != nil && p.Package != nil {
+		for _, imp := range p.Imports() {
+			if imp.Name() == name {
+				return imp, nil
+			}
+		}
+	}
+
+	return p.importPackage(name)
+}
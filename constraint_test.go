@@ -25,3 +25,286 @@ func TestTryType(t *testing.T) {
 		}
 	}
 }
+
+func TestTryKinds(t *testing.T) {
+	p, _ := testPackage(t, `package kinds
+
+type (
+	Int      int
+	Uint8    uint8
+	Float    float64
+	Str      string
+	Bool     bool
+	Struct   struct{}
+	Slice    []int
+	Map      map[string]int
+	Ptr      *int
+	Iface    interface{}
+	Chan     chan int
+	Func     func()
+)
+`)
+
+	tests := []struct {
+		typ        string
+		constraint Constraint
+		ok         bool
+	}{
+		{"Int", Constraint{Integer: true, Signed: true}, true},
+		{"Int", Constraint{Unsigned: true}, false},
+		{"Int", Constraint{Float: true}, false},
+		{"Uint8", Constraint{Integer: true, Unsigned: true, Numeric: true}, true},
+		{"Uint8", Constraint{Signed: true}, false},
+		{"Float", Constraint{Float: true, Ordered: true}, true},
+		{"Float", Constraint{Integer: true}, false},
+		{"Str", Constraint{String: true, Ordered: true}, true},
+		{"Str", Constraint{Boolean: true}, false},
+		{"Bool", Constraint{Boolean: true, Comparable: true}, true},
+		{"Struct", Constraint{Struct: true}, true},
+		{"Struct", Constraint{Slice: true}, false},
+		{"Slice", Constraint{Slice: true}, true},
+		{"Map", Constraint{Map: true}, true},
+		{"Ptr", Constraint{Pointer: true}, true},
+		{"*Int", Constraint{Pointer: true}, true},
+		{"Int", Constraint{Pointer: true}, false},
+		{"Iface", Constraint{Interface: true}, true},
+		{"Chan", Constraint{Channel: true}, true},
+		{"Func", Constraint{Func: true}, true},
+		{"Func", Constraint{Map: true}, false},
+	}
+
+	for i, test := range tests {
+		typ, err := p.Eval(test.typ)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = test.constraint.TryType(typ)
+
+		if test.ok != (err == nil) {
+			t.Errorf("[test %v] TryType of %s should be %v, got %v", i, test.typ, test.ok, err)
+		}
+	}
+
+	// errors describe the underlying type
+	typ, _ := p.Eval("Str")
+	err := Constraint{Integer: true}.TryType(typ)
+
+	if want := "Str must be an integer (its underlying type is string)"; err == nil || err.Error() != want {
+		t.Errorf("error should be %q, got %v", want, err)
+	}
+}
+
+func TestTryMethods(t *testing.T) {
+	p, _ := testPackage(t, `package methods
+
+import (
+	"fmt"
+	"io"
+)
+
+type Lesser interface {
+	Less(Value) bool
+}
+
+type Value int
+
+func (v Value) String() string     { return "" }
+func (v Value) Less(o Value) bool  { return v < o }
+func (v Value) Write(w io.Writer)  {}
+
+type Pointer struct{}
+
+func (p *Pointer) String() string                     { return "" }
+func (p *Pointer) Format(f fmt.State, verb rune)      {}
+func (p *Pointer) MarshalJSON() ([]byte, error)       { return nil, nil }
+
+type Wrong int
+
+func (w Wrong) String() int { return 0 }
+
+type Err struct{}
+
+func (Err) Error() string { return "" }
+`)
+
+	tests := []struct {
+		typ        string
+		constraint Constraint
+		err        string
+	}{
+		{"Value", Constraint{Implements: "fmt.Stringer"}, ""},
+		{"Value", Constraint{Implements: "Lesser"}, ""},
+		{"Value", Constraint{Method: "Less(T) bool"}, ""},
+		{"Value", Constraint{Method: "Write(io.Writer)"}, ""},
+		{"Value", Constraint{Method: "Write(w io.Reader)"}, "Value must have method Write(w io.Reader) (wrong type for method Write)"},
+		{"Value", Constraint{Implements: "encoding/json.Marshaler"}, "Value must implement encoding/json.Marshaler (missing method MarshalJSON)"},
+		{"Value", Constraint{Implements: "error"}, "Value must implement error (missing method Error)"},
+		{"Pointer", Constraint{Implements: "fmt.Stringer"}, ""},
+		{"*Pointer", Constraint{Implements: "fmt.Stringer"}, ""},
+		{"Pointer", Constraint{Implements: "fmt.Formatter"}, ""},
+		{"Pointer", Constraint{Method: "Format(fmt.State, rune)"}, ""},
+		{"Pointer", Constraint{Implements: "encoding/json.Marshaler"}, ""},
+		{"Pointer", Constraint{Method: "Less(T) bool"}, "Pointer must have method Less(T) bool (missing method Less)"},
+		{"Wrong", Constraint{Implements: "fmt.Stringer"}, "Wrong must implement fmt.Stringer (wrong type for method String)"},
+		{"Err", Constraint{Implements: "error", Struct: true}, ""},
+		{"Value", Constraint{Implements: "Value"}, "Value is not an interface"},
+		{"Value", Constraint{Implements: "fmt.Nope"}, "fmt.Nope is not an interface"},
+		{"Value", Constraint{Method: "Less(Nope) bool"}, `invalid method "Less(Nope) bool": undefined: Nope`},
+		{"Value", Constraint{Method: "Less(T bool"}, `invalid method "Less(T bool": missing ',' in parameter list`},
+	}
+
+	for i, test := range tests {
+		typ, err := p.Eval(test.typ)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = test.constraint.TryType(typ)
+
+		if test.err == "" && err != nil {
+			t.Errorf("[test %v] %v", i, err)
+		}
+
+		if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("[test %v] error should be %q, got %v", i, test.err, err)
+		}
+	}
+}
+
+func TestTryComposite(t *testing.T) {
+	p, _ := testPackage(t, `package composite
+
+type Value int
+
+type Thing struct{}
+
+func (t Thing) Less(o Thing) bool { return false }
+
+type Other struct{}
+`)
+
+	orderedOrLess := Constraint{Any: []Constraint{{Ordered: true}, {Method: "Less(T) bool"}}}
+
+	tests := []struct {
+		typ        string
+		constraint Constraint
+		err        string
+	}{
+		{"Value", orderedOrLess, ""},
+		{"Thing", orderedOrLess, ""},
+		{"Other", orderedOrLess, "Other satisfies no alternative: Other must be ordered (i.e. support > and <), or Other must have method Less(T) bool (missing method Less)"},
+		{"Value", Constraint{All: []Constraint{{Integer: true}, {Signed: true}}}, ""},
+		{"Value", Constraint{All: []Constraint{{Integer: true}, {Unsigned: true}}}, "Value must be an unsigned integer (its underlying type is int)"},
+		{"Thing", Constraint{Not: &Constraint{Ordered: true}}, ""},
+		{"Value", Constraint{Not: &Constraint{Ordered: true}}, "Value must not be ordered"},
+		{"Value", Constraint{Comparable: true, Not: &Constraint{Any: []Constraint{{String: true}, {Integer: true}}}}, "Value must not (be a string, or be an integer)"},
+		{"Thing", Constraint{Struct: true, Not: &Constraint{Not: &Constraint{Method: "Less(T) bool"}}}, ""},
+		{"Other", Constraint{Not: &Constraint{Implements: "fmt.Nope"}}, "fmt.Nope is not an interface"},
+		{"Other", Constraint{Any: []Constraint{{Struct: true}, {Implements: "fmt.Nope"}}}, ""},
+		{"Other", Constraint{Any: []Constraint{{Ordered: true}, {Implements: "fmt.Nope"}}}, "fmt.Nope is not an interface"},
+	}
+
+	for i, test := range tests {
+		typ, err := p.Eval(test.typ)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = test.constraint.TryType(typ)
+
+		if test.err == "" && err != nil {
+			t.Errorf("[test %v] %v", i, err)
+		}
+
+		if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("[test %v] error should be %q, got %v", i, test.err, err)
+		}
+	}
+
+	// composite constraints apply to type parameters
+	tmpl := &Template{
+		Name:                     "Sort",
+		TypeParameterConstraints: []Constraint{orderedOrLess},
+	}
+
+	value, _ := p.Eval("Value")
+	other, _ := p.Eval("Other")
+
+	if err := tmpl.TryTypeAndValue(value, TagValue{Name: "Sort", TypeParameters: []Type{value}}); err != nil {
+		t.Error(err)
+	}
+
+	if err := tmpl.TryTypeAndValue(value, TagValue{Name: "Sort", TypeParameters: []Type{other}}); err == nil {
+		t.Errorf("Other should not satisfy the type parameter constraint")
+	}
+}
+
+func TestTrySatisfies(t *testing.T) {
+	p, _ := testPackage(t, `package satisfies
+
+type Value int
+
+type Name string
+
+type Thing struct{}
+
+func (t Thing) String() string { return "" }
+
+type Funcs struct {
+	f func()
+}
+`)
+
+	tests := []struct {
+		typ        string
+		constraint Constraint
+		err        string
+	}{
+		{"Value", Constraint{Satisfies: "comparable"}, ""},
+		{"Value", Constraint{Satisfies: "cmp.Ordered"}, ""},
+		{"Name", Constraint{Satisfies: "interface{ ~int | ~string }"}, ""},
+		{"Thing", Constraint{Satisfies: "interface{ comparable; fmt.Stringer }"}, ""},
+		{"Funcs", Constraint{Satisfies: "comparable"}, "Funcs does not satisfy comparable (it is not comparable)"},
+		{"Thing", Constraint{Satisfies: "cmp.Ordered"}, "Thing does not satisfy cmp.Ordered (satisfies.Thing is not in the type set)"},
+		{"Value", Constraint{Satisfies: "fmt.Stringer"}, "Value does not satisfy fmt.Stringer (missing method String)"},
+		{"Name", Constraint{Satisfies: "~int | ~string"}, ""},
+		{"Value", Constraint{Satisfies: "T | string"}, ""},
+		{"Value", Constraint{Not: &Constraint{Satisfies: "~string"}}, ""},
+		{"Value", Constraint{Satisfies: "~string"}, "Value does not satisfy ~string (satisfies.Value is not in the type set)"},
+		{"Value", Constraint{Satisfies: "Nope"}, `invalid constraint "Nope": undefined: Nope`},
+		{"Value", Constraint{Satisfies: "~Value"}, `invalid constraint "~Value": undefined: Value`},
+		{"Value", Constraint{Satisfies: "~T"}, `invalid constraint "~T": invalid use of ~ (underlying type of satisfies.Value is int)`},
+		{"Value", Constraint{Satisfies: "int |"}, `invalid constraint "int |": expected ~ term or type, found '}'`},
+	}
+
+	for i, test := range tests {
+		typ, err := p.Eval(test.typ)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = test.constraint.TryType(typ)
+
+		if test.err == "" && err != nil {
+			t.Errorf("[test %v] %v", i, err)
+		}
+
+		if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("[test %v] error should be %q, got %v", i, test.err, err)
+		}
+	}
+
+	// type-checked once, and cached
+	if _, ok := p.constraints["cmp.Ordered"]; !ok {
+		t.Errorf("cmp.Ordered should have been cached")
+	}
+
+	if _, ok := p.constraints["T | string"]; ok {
+		t.Errorf("a constraint referring to T should not be cached")
+	}
+}
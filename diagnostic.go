@@ -0,0 +1,82 @@
+package typewriter
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// Diagnostic describes a problem with an annotation or its application, as data, for use by editors and other tools.
+// Use errors.As with an *Error to retrieve a Diagnostic from an error returned by this package.
+type Diagnostic struct {
+	// Pos and End delimit the offending text; End may equal Pos, and both may be invalid if the source is unknown
+	Pos, End token.Position
+	Severity Severity
+	Code     Code
+	// The offending tag and value, if known
+	Tag, Value string
+	Msg        string
+}
+
+func (d Diagnostic) String() string {
+	if d.Pos.IsValid() {
+		return fmt.Sprintf("%s: %s", d.Pos, d.Msg)
+	}
+	return d.Msg
+}
+
+// Severity indicates whether a Diagnostic prevents code generation.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	// Warnings do not prevent code generation, eg type check errors when Config.IgnoreTypeCheckErrors is set
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Code is a stable identifier for the kind of a Diagnostic. Codes will not be renumbered, though new codes may be added.
+type Code string
+
+const (
+	// A tag value for which no template exists
+	CodeUnknownTagValue Code = "TW001"
+	// A tag for which no template exists
+	CodeUnknownTag Code = "TW002"
+	// A type which does not meet the type constraint of a template
+	CodeTypeConstraint Code = "TW003"
+	// A tag value with the wrong number of type parameters for its template
+	CodeTypeParameterCount Code = "TW004"
+	// A type parameter which does not meet the constraint of a template
+	CodeTypeParameterConstraint Code = "TW005"
+	// A directive which can't be parsed
+	CodeSyntax Code = "TW006"
+	// A tag which appears more than once on a declaration
+	CodeDuplicateTag Code = "TW007"
+	// A pointer declaration which is misplaced, repeated or not valid on the declaration
+	CodeInvalidPointer Code = "TW008"
+	// An annotated type or type parameter which does not type check
+	CodeTypeCheck Code = "TW009"
+	// A target tag which does not refer to an exported type in another package
+	CodeInvalidTarget Code = "TW010"
+	// An annotation file which can't be read
+	CodeAnnotationFile Code = "TW011"
+	// An annotation file which conflicts with a directive comment
+	CodeConflict Code = "TW012"
+	// An annotation file which refers to a type which is not declared
+	CodeUndeclared Code = "TW013"
+	// A type on which methods may not be declared, such as an alias of a type from another package
+	CodeInvalidReceiver Code = "TW014"
+	// A template constraint which can't be parsed or evaluated, rather than a type which does not meet it
+	CodeInvalidConstraint Code = "TW015"
+	// A generated file which would be written by more than one directive or typewriter
+	CodeDuplicateFile Code = "TW016"
+)
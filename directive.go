@@ -0,0 +1,40 @@
+package typewriter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// goStyle reports whether the directive is of the form name:, eg //gen:slice
+func goStyle(directive string) bool {
+	return strings.HasSuffix(directive, ":")
+}
+
+// directiveName returns the directive without its punctuation, eg gen for +gen or gen:
+func directiveName(directive string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(directive, "+"), ":")
+}
+
+// validateDirective returns an error if the directive is of neither form
+func validateDirective(directive string) error {
+	name := directiveName(directive)
+
+	valid := len(name) > 0 && len(name) == len(directive)-1
+	for _, r := range name {
+		valid = valid && isAlphaNumeric(r)
+	}
+
+	if !valid {
+		return fmt.Errorf("invalid directive %q; expected the form +name or name:", directive)
+	}
+
+	return nil
+}
+
+// directivePrefix returns the text which precedes the tags of a directive comment, eg "// +gen " or "//gen:"
+func directivePrefix(directive string) string {
+	if goStyle(directive) {
+		return "//" + directive
+	}
+	return "// " + directive + " "
+}
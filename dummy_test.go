@@ -8,5 +8,27 @@ type (
 	dummy2 map[string]dummy
 
 	// +test foo:"bar"
+	//other:bar
 	dummy3 string
 )
+
+// +test foo:"bar"
+func dummyFunc(d dummy) dummy {
+	return d
+}
+
+// +test foo:"bar"
+func (d dummy) dummyMethod() {}
+
+// +test foo:"bar"
+const (
+	dummyConst1 dummy = iota
+	dummyConst2
+)
+
+var (
+	// +test foo:"bar"
+	dummyVar1, dummyVar2 dummy3
+
+	dummyVar3 dummy3
+)
@@ -0,0 +1,106 @@
+package typewriter
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Error is an error in an annotation, described by its Diagnostic. Errors returned by this package
+// can be inspected with errors.As:
+//
+//	var e *typewriter.Error
+//	if errors.As(err, &e) {
+//		fmt.Println(e.Code, e.Pos, e.End)
+//	}
+type Error struct {
+	Diagnostic
+}
+
+func (e *Error) Error() string {
+	return e.Diagnostic.String()
+}
+
+func newError(pos token.Position, code Code, format string, args ...interface{}) *Error {
+	return &Error{Diagnostic{Pos: pos, End: pos, Code: code, Msg: fmt.Sprintf(format, args...)}}
+}
+
+// ErrorList is a list of *Errors, such that all errors in the annotations of a package can be reported at once.
+type ErrorList []*Error
+
+// Add adds an Error with the given position, code and message.
+func (l *ErrorList) Add(pos token.Position, code Code, msg string) {
+	*l = append(*l, &Error{Diagnostic{Pos: pos, End: pos, Code: code, Msg: msg}})
+}
+
+// add adds an error, flattening ErrorLists; errors which are not an *Error are added without a position.
+func (l *ErrorList) add(err error) {
+	switch e := err.(type) {
+	case nil:
+		return
+	case *Error:
+		*l = append(*l, e)
+	case ErrorList:
+		*l = append(*l, e...)
+	default:
+		l.Add(token.Position{}, "", err.Error())
+	}
+}
+
+func (l ErrorList) Len() int      { return len(l) }
+func (l ErrorList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+func (l ErrorList) Less(i, j int) bool {
+	e, f := l[i].Pos, l[j].Pos
+	if e.Filename != f.Filename {
+		return e.Filename < f.Filename
+	}
+	if e.Line != f.Line {
+		return e.Line < f.Line
+	}
+	if e.Column != f.Column {
+		return e.Column < f.Column
+	}
+	return l[i].Msg < l[j].Msg
+}
+
+// Sort sorts the list by position (file name, line, column), then message.
+func (l ErrorList) Sort() {
+	sort.Sort(l)
+}
+
+// Error returns all of the errors, one per line.
+func (l ErrorList) Error() string {
+	var errs []string
+	for _, e := range l {
+		errs = append(errs, e.Error())
+	}
+	return strings.Join(errs, "\n")
+}
+
+// Err returns an error equivalent to this list, or nil if the list is empty.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// Unwrap returns the errors of the list, such that errors.As finds the first *Error.
+func (l ErrorList) Unwrap() []error {
+	var errs []error
+	for _, e := range l {
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// Diagnostics returns the Diagnostic of each error in the list.
+func (l ErrorList) Diagnostics() []Diagnostic {
+	var result []Diagnostic
+	for _, e := range l {
+		result = append(result, e.Diagnostic)
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+package typewriter
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	fset := token.NewFileSet()
+	f := fset.AddFile("dummy.go", -1, 1000)
+
+	c := &ast.Comment{
+		Slash: f.Pos(0),
+		Text:  `// +test foo:"bar" qux foo:"-a,--b" * thing:"x-"`,
+	}
+
+	_, _, err := parse(fset, c, "+test")
+
+	// the first error should be found by errors.As
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("should be able to retrieve an *Error from %v", err)
+	}
+
+	if e.Code != CodeDuplicateTag || e.Tag != "foo" {
+		t.Errorf("first error should be a duplicate foo tag, got %s %q", e.Code, e.Tag)
+	}
+
+	if e.Pos.Column != 24 || e.End.Column != 27 {
+		t.Errorf("duplicate tag should span columns 24 to 27, got %v to %v", e.Pos.Column, e.End.Column)
+	}
+
+	diags := err.(ErrorList).Diagnostics()
+
+	expected := []struct {
+		code Code
+		tag  string
+	}{
+		{CodeDuplicateTag, "foo"},
+		{CodeSyntax, "foo"},
+		{CodeInvalidPointer, ""},
+		{CodeSyntax, "thing"},
+	}
+
+	if len(diags) != len(expected) {
+		t.Fatalf("should have found %v diagnostics, found %v:\n%s", len(expected), len(diags), err)
+	}
+
+	for i, x := range expected {
+		d := diags[i]
+		if d.Code != x.code || d.Tag != x.tag || d.Severity != SeverityError {
+			t.Errorf("[diagnostic %v] expected %s %q, got %s %q %s", i, x.code, x.tag, d.Code, d.Tag, d.Severity)
+		}
+	}
+
+	// template errors
+	tmpl := &Template{
+		Name:                     "Thing",
+		TypeConstraint:           Constraint{Numeric: true},
+		TypeParameterConstraints: []Constraint{{}},
+	}
+
+	// a constraint which can't be evaluated
+	invalid := &Template{
+		Name:           "Thing",
+		TypeConstraint: Constraint{Implements: "fmt.Nope"},
+	}
+
+	invalidParameter := &Template{
+		Name:                     "Thing",
+		TypeParameterConstraints: []Constraint{{Implements: "fmt.Nope"}},
+	}
+
+	templateErrors := []struct {
+		err  error
+		code Code
+	}{
+		{tmpl.TryTypeAndValue(Type{Name: "foo"}, TagValue{Name: "Thing"}), CodeTypeConstraint},
+		{tmpl.TryTypeAndValue(Type{Name: "foo", numeric: true}, TagValue{Name: "Thing"}), CodeTypeParameterCount},
+		{func() error { _, err := TemplateSlice{tmpl}.ByTagValue(Type{}, TagValue{Name: "Other"}); return err }(), CodeUnknownTagValue},
+		{func() error { _, err := TemplateSlice{tmpl}.ByTag(Type{}, Tag{Name: "Other"}); return err }(), CodeUnknownTag},
+		{func() error { _, err := TemplateSlice{tmpl}.ByTag(Type{}, Tag{Name: "Thing"}); return err }(), CodeTypeConstraint},
+		{func() error { _, err := TemplateSlice{invalid}.ByTag(Type{}, Tag{Name: "Thing"}); return err }(), CodeInvalidConstraint},
+		{invalid.TryTypeAndValue(Type{Name: "foo"}, TagValue{Name: "Thing"}), CodeInvalidConstraint},
+		{invalidParameter.TryTypeAndValue(Type{Name: "foo"}, TagValue{Name: "Thing", TypeParameters: []Type{{Name: "bar"}}}), CodeInvalidConstraint},
+	}
+
+	for i, x := range templateErrors {
+		var e *Error
+		if !errors.As(x.err, &e) || e.Code != x.code {
+			t.Errorf("[template error %v] should be %s, got %v", i, x.code, x.err)
+		}
+	}
+
+	// type check errors
+	tc := &TypeCheckError{fmt.Errorf("-: undeclared name: notreal"), true}
+	tc.addPos(fset, f.Pos(3))
+
+	if !errors.As(tc, &e) || e.Code != CodeTypeCheck || e.Severity != SeverityWarning || e.Pos.Column != 4 {
+		t.Errorf("ignored type check error should be a warning at column 4, got %v", tc)
+	}
+
+	if e.Msg != "undeclared name: notreal" {
+		t.Errorf("position should have been trimmed from message, got %q", e.Msg)
+	}
+}
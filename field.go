@@ -0,0 +1,171 @@
+package typewriter
+
+import (
+	"go/token"
+	"go/types"
+	"reflect"
+)
+
+// Field describes a field of a struct type, with the tags of its directive comment, if any.
+// Directives may appear in the field's doc comment, or in its trailing line comment, eg:
+//
+//	Name string // +gen builder:"-"
+type Field struct {
+	Name     string
+	Tags     TagSlice
+	Type     Type // eg string, or *token.Position for a field of a type from another package
+	Embedded bool // named for its type, eg the Point of struct{ Point; Z int }
+	Exported bool
+	// Promoted from an embedded struct, eg the X of struct{ Point; Z int }. Promoted fields follow
+	// the fields of the struct itself, shallowest first; ambiguous ones are omitted, as in Go.
+	Promoted bool
+	Tag      reflect.StructTag // eg `json:"name"`, which templates might use as {{.Tag.Get "json"}}
+	Position token.Position
+}
+
+func (f Field) FindTag(tw Interface) (Tag, bool) {
+	return findTag(f.Tags, tw.Name())
+}
+
+// structOf returns the struct underlying the (possibly pointed-to) type, or nil
+func structOf(typ types.Type) *types.Struct {
+	if ptr, ok := types.Unalias(typ).(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	st, _ := typ.Underlying().(*types.Struct)
+	return st
+}
+
+// fields returns the fields of a struct type, including promoted fields, with tags from the directives of annotated fields
+func (p *Package) fields(typ types.Type, annotated []Field) []Field {
+	st := structOf(typ)
+	if st == nil {
+		return nil
+	}
+
+	var result []Field
+	seen := make(map[string]bool)
+
+	for i := 0; i < st.NumFields(); i++ {
+		f := p.newField(st, i, false)
+		if a, ok := findField(annotated, f.Name); ok {
+			f.Tags = a.Tags
+		}
+		seen[f.Name] = true
+		result = append(result, f)
+	}
+
+	// breadth-first through embedded structs, such that shallower fields shadow deeper ones; as with
+	// types.LookupFieldOrMethod, a struct reached by more than one path at a depth makes its fields ambiguous
+	visited := make(map[types.Type]bool)
+	embedded := embeddedStructs(st, 1)
+
+	for len(embedded) > 0 {
+		var next []embedding
+		var depth []Field
+		count := make(map[string]int)
+
+		for _, e := range consolidate(embedded) {
+			// a struct visited at a shallower depth is shadowed there, and might embed itself
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := 0; i < e.st.NumFields(); i++ {
+				f := p.newField(e.st, i, true)
+				if seen[f.Name] {
+					continue
+				}
+				count[f.Name] += e.paths
+				depth = append(depth, f)
+			}
+			next = append(next, embeddedStructs(e.st, e.paths)...)
+		}
+
+		for _, f := range depth {
+			if count[f.Name] == 1 {
+				result = append(result, f)
+			}
+		}
+
+		// ambiguous names shadow deeper fields, too
+		for name := range count {
+			seen[name] = true
+		}
+
+		embedded = next
+	}
+
+	return result
+}
+
+// embedding is a struct embedded in another, by way of its (possibly pointed-to) type
+type embedding struct {
+	typ   types.Type
+	st    *types.Struct
+	paths int // by which the struct is embedded, at its depth
+}
+
+// embeddedStructs returns the structs embedded in st, each reached by the given number of paths
+func embeddedStructs(st *types.Struct, paths int) []embedding {
+	var result []embedding
+
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		if !v.Embedded() {
+			continue
+		}
+
+		typ := types.Unalias(v.Type())
+		if ptr, ok := typ.(*types.Pointer); ok {
+			typ = types.Unalias(ptr.Elem())
+		}
+
+		if e, ok := typ.Underlying().(*types.Struct); ok {
+			result = append(result, embedding{typ, e, paths})
+		}
+	}
+
+	return result
+}
+
+// consolidate combines the embeddings of the same type, adding up their paths
+func consolidate(embedded []embedding) []embedding {
+	var result []embedding
+	index := make(map[types.Type]int)
+
+	for _, e := range embedded {
+		if i, ok := index[e.typ]; ok {
+			result[i].paths += e.paths
+			continue
+		}
+		index[e.typ] = len(result)
+		result = append(result, e)
+	}
+
+	return result
+}
+
+func (p *Package) newField(st *types.Struct, i int, promoted bool) Field {
+	v := st.Field(i)
+
+	return Field{
+		Name:     v.Name(),
+		Type:     p.typeOf(v.Type()),
+		Embedded: v.Embedded(),
+		Exported: v.Exported(),
+		Promoted: promoted,
+		Tag:      reflect.StructTag(st.Tag(i)),
+		Position: p.position(v.Pos()),
+	}
+}
+
+func findField(fields []Field, name string) (Field, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
@@ -0,0 +1,171 @@
+package typewriter
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestFields(t *testing.T) {
+	p, f := testPackage(t, `package fields
+
+import "go/token"
+
+// +test foo
+type Thing struct {
+	Name string `+"`json:\"name\"`"+` // +test foo:"bar"
+	age  int
+	Point
+	*Node
+	token.Position
+}
+
+type Point struct {
+	X, Y int
+	Name string // shadowed by Thing.Name
+}
+
+type Node struct {
+	Next *Node
+	Left
+	Right
+}
+
+type Left struct {
+	Value, L int
+}
+
+type Right struct {
+	Value, R int
+}
+`)
+
+	a := &ast.Package{
+		Name:  f.Name.Name,
+		Files: map[string]*ast.File{"test.go": f},
+	}
+
+	var errs ErrorList
+	p.annotate(a, "+test", nil, &errs, func(error, token.Pos) bool { return false })
+
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	if len(p.Types) != 1 {
+		t.Fatalf("should have found 1 type, found %v", len(p.Types))
+	}
+
+	expected := []struct {
+		name     string
+		typ      string
+		embedded bool
+		exported bool
+		promoted bool
+		line     int
+	}{
+		{"Name", "string", false, true, false, 7},
+		{"age", "int", false, false, false, 8},
+		{"Point", "Point", true, true, false, 9},
+		{"Node", "*Node", true, true, false, 10},
+		{"Position", "token.Position", true, true, false, 11},
+		{"X", "int", false, true, true, 15},
+		{"Y", "int", false, true, true, 15},
+		{"Next", "*Node", false, true, true, 20},
+		{"Left", "Left", true, true, true, 21},
+		{"Right", "Right", true, true, true, 22},
+		{"Filename", "string", false, true, true, 0},
+		{"Offset", "int", false, true, true, 0},
+		{"Line", "int", false, true, true, 0},
+		{"Column", "int", false, true, true, 0},
+		// Value is ambiguous
+		{"L", "int", false, true, true, 26},
+		{"R", "int", false, true, true, 30},
+	}
+
+	fields := p.Types[0].Fields()
+
+	if len(fields) != len(expected) {
+		t.Fatalf("should have found %v fields, found %v", len(expected), fields)
+	}
+
+	for i, e := range expected {
+		f := fields[i]
+
+		if f.Name != e.name || f.Type.String() != e.typ || f.Embedded != e.embedded || f.Exported != e.exported || f.Promoted != e.promoted {
+			t.Errorf("[field %v] should have been %v, got %+v", i, e, f)
+		}
+
+		if e.line > 0 && f.Position.Line != e.line {
+			t.Errorf("[field %v] should have been at line %v, got %v", i, e.line, f.Position)
+		}
+	}
+
+	name := fields[0]
+
+	if name.Tag.Get("json") != "name" {
+		t.Errorf("struct tag should have been parsed, got %q", name.Tag)
+	}
+
+	if tag, ok := name.FindTag(&fooWriter{}); !ok || len(tag.Values) != 1 || tag.Values[0].Name != "bar" {
+		t.Errorf("should have found the foo tag of the directive, got %v", name.Tags)
+	}
+
+	if !fields[3].Type.Pointer {
+		t.Errorf("the type of an embedded pointer should be a pointer")
+	}
+
+	// fields of fields
+	if n := len(fields[2].Type.Fields()); n != 3 {
+		t.Errorf("Point should have 3 fields, found %v", n)
+	}
+
+	if typ, _ := p.Eval("int"); typ.Fields() != nil {
+		t.Errorf("a type which is not a struct should have no fields")
+	}
+
+	// a struct embedded by two paths at the same depth makes its fields ambiguous, as in Go
+	p, f = testPackage(t, `package fields
+
+// +test foo
+type T struct {
+	A
+	B
+}
+
+type A struct {
+	C
+	Z int
+}
+
+type B struct {
+	C
+}
+
+type C struct {
+	X int
+}
+`)
+
+	a = &ast.Package{
+		Name:  f.Name.Name,
+		Files: map[string]*ast.File{"test.go": f},
+	}
+
+	p.annotate(a, "+test", nil, &errs, func(error, token.Pos) bool { return false })
+
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	var names []string
+	for _, f := range p.Types[0].Fields() {
+		names = append(names, f.Name)
+	}
+
+	// C and its X are reached by way of both A and B
+	if strings.Join(names, ",") != "A,B,Z" {
+		t.Errorf("T should have fields A,B,Z, found %v", names)
+	}
+}
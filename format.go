@@ -0,0 +1,219 @@
+package typewriter
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+)
+
+// FormatDirective renders a pointer declaration and tags as a directive comment in canonical form, eg:
+//
+//	// +gen * set slice:"Where,GroupBy[string]"
+//	//gen:* set slice:"Where,GroupBy[string]"
+//
+// Tags are sorted by name and separated by single spaces. Values and type parameters keep their order.
+func FormatDirective(directive string, pointer Pointer, tags TagSlice) string {
+	var parts []string
+
+	if pointer {
+		parts = append(parts, pointer.String())
+	}
+
+	sorted := make(TagSlice, len(tags))
+	copy(sorted, tags)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	for _, tag := range sorted {
+		parts = append(parts, tag.String())
+	}
+
+	return strings.TrimSpace(directivePrefix(directive) + strings.Join(parts, " "))
+}
+
+// String renders the tag as it would be written in a directive, eg slice:"-Where,Count"
+func (t Tag) String() string {
+	if len(t.Values) == 0 && !t.Negated {
+		return t.Name
+	}
+
+	var vals []string
+	for _, v := range t.Values {
+		vals = append(vals, v.String())
+	}
+
+	var minus string
+	if t.Negated {
+		minus = "-"
+	}
+
+	return t.Name + `:"` + minus + strings.Join(vals, ",") + `"`
+}
+
+// String renders the value as it would be written in a directive, eg GroupBy[string] or -Count
+func (v TagValue) String() string {
+	var prefix string
+	switch {
+	case v.Negated:
+		prefix = "-"
+	case v.Added:
+		prefix = "+"
+	}
+
+	var params []string
+
+	if len(v.typeParameters) > 0 {
+		// as written, prior to evaluation
+		for _, item := range v.typeParameters {
+			params = append(params, item.val)
+		}
+	} else {
+		for _, t := range v.TypeParameters {
+			params = append(params, t.String())
+		}
+	}
+
+	if len(params) == 0 {
+		return prefix + v.Name
+	}
+
+	return prefix + v.Name + "[" + strings.Join(params, ", ") + "]"
+}
+
+// FormatDirectives rewrites the directive comments in the current directory into canonical form, in the manner of gofmt.
+// It returns the names of the files which were changed. See FormatDirective.
+func FormatDirectives(directive string) ([]string, error) {
+	return DefaultConfig.FormatDirectives(directive)
+}
+
+// FormatDirectives rewrites the directive comments in the current directory into canonical form, in the manner of gofmt.
+// Comments which fail to parse are left as they are, and their errors returned as an ErrorList.
+func (conf *Config) FormatDirectives(directive string) ([]string, error) {
+	return conf.formatDirectives(directive, true)
+}
+
+// UnformattedDirectives returns the names of the files in the current directory whose directive comments are not in
+// canonical form, without rewriting them, in the manner of gofmt -l. See FormatDirectives.
+func UnformattedDirectives(directive string) ([]string, error) {
+	return DefaultConfig.UnformattedDirectives(directive)
+}
+
+// UnformattedDirectives returns the names of the files in the current directory whose directive comments are not in
+// canonical form, without rewriting them, in the manner of gofmt -l. See FormatDirectives.
+func (conf *Config) UnformattedDirectives(directive string) ([]string, error) {
+	return conf.formatDirectives(directive, false)
+}
+
+// formatDirectives returns the names of the files whose directive comments are not in canonical form, rewriting them if write
+func (conf *Config) formatDirectives(directive string, write bool) ([]string, error) {
+	if err := validateDirective(directive); err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	astPkgs, err := parser.ParseDir(fset, "./", conf.filter(), parser.ParseComments)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	var errs ErrorList
+
+	for _, a := range astPkgs {
+		// sort files for a predictable order
+		var names []string
+		for name := range a.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			edits, err := formatFile(fset, a.Files[name], directive)
+			errs.add(err)
+
+			if len(edits) == 0 {
+				continue
+			}
+
+			if write {
+				if err := applyEdits(name, edits); err != nil {
+					return result, err
+				}
+			}
+
+			result = append(result, name)
+		}
+	}
+
+	errs.Sort()
+	return result, errs.Err()
+}
+
+// edit replaces the bytes of a file from offset to end
+type edit struct {
+	offset, end int
+	text        string
+}
+
+// formatFile returns edits to the directive comments of a file which are not in canonical form
+func formatFile(fset *token.FileSet, f *ast.File, directive string) ([]edit, error) {
+	var edits []edit
+	var errs ErrorList
+
+	for _, g := range f.Comments {
+		c := findAnnotation(g, directive)
+		if c == nil {
+			continue
+		}
+
+		pointer, tags, err := parse(fset, c, directive)
+
+		if err != nil {
+			errs.add(err)
+			continue
+		}
+
+		text := FormatDirective(directive, pointer, tags)
+		if text == c.Text {
+			continue
+		}
+
+		offset := fset.Position(c.Slash).Offset
+		edits = append(edits, edit{offset, offset + len(c.Text), text})
+	}
+
+	return edits, errs.Err()
+}
+
+// applyEdits rewrites a file with edits, which are in order of offset
+func applyEdits(filename string, edits []edit) error {
+	info, err := os.Stat(filename)
+
+	if err != nil {
+		return err
+	}
+
+	src, err := os.ReadFile(filename)
+
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	last := 0
+
+	for _, e := range edits {
+		b.Write(src[last:e.offset])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.Write(src[last:])
+
+	return os.WriteFile(filename, b.Bytes(), info.Mode())
+}
@@ -0,0 +1,164 @@
+package typewriter
+
+import (
+	"go/ast"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatDirective(t *testing.T) {
+	tests := []struct {
+		comment, formatted string
+	}{
+		{`// +test`, `// +test`},
+		{`//+test   foo`, `// +test foo`},
+		{`// +test *  foo bar`, `// +test * bar foo`},
+		{`// +test foo:"bar, Baz" qux`, `// +test foo:"bar,Baz" qux`},
+		{`// +test qux:"-thing" foo:"bar"`, `// +test foo:"bar" qux:"-thing"`},
+		{`// +test foo:"bar[int,  string],baz"`, `// +test foo:"bar[int, string],baz"`},
+		{`// +test foo:"bar[map[string]*Thing]"`, `// +test foo:"bar[map[string]*Thing]"`},
+		{`// +test target:"github.com/x/pb.User"`, `// +test target:"github.com/x/pb.User"`},
+		{`// +test foo:"-"`, `// +test foo:"-"`},
+		{`// +test foo:"-bar, Baz"`, `// +test foo:"-bar,Baz"`},
+		{`// +test foo:"bar, -Baz,+qux[int]"`, `// +test foo:"bar,-Baz,+qux[int]"`},
+	}
+
+	for i, test := range tests {
+		fset := token.NewFileSet()
+		file := fset.AddFile("test.go", -1, len(test.comment)+1)
+		c := &ast.Comment{
+			Slash: file.Pos(0),
+			Text:  test.comment,
+		}
+
+		pointer, tags, err := parse(fset, c, "+test")
+
+		if err != nil {
+			t.Errorf("[test %v] %v", i, err)
+			continue
+		}
+
+		if formatted := FormatDirective("+test", pointer, tags); formatted != test.formatted {
+			t.Errorf("[test %v] formatted should be %q, got %q", i, test.formatted, formatted)
+		}
+	}
+
+	// directives in the style of //go:generate
+	tags := TagSlice{
+		{Name: "foo", Values: []TagValue{{Name: "bar"}}},
+	}
+
+	if formatted, want := FormatDirective("test:", true, tags), `//test:* foo:"bar"`; formatted != want {
+		t.Errorf("formatted should be %q, got %q", want, formatted)
+	}
+
+	if formatted, want := FormatDirective("test:", false, nil), `//test:`; formatted != want {
+		t.Errorf("formatted should be %q, got %q", want, formatted)
+	}
+
+	// evaluated type parameters
+	tags = TagSlice{
+		{Name: "foo", Values: []TagValue{{Name: "bar", TypeParameters: []Type{{Name: "int"}, {Pointer: true, Name: "Thing"}}}}},
+	}
+
+	if formatted, want := FormatDirective("+test", false, tags), `// +test foo:"bar[int, *Thing]"`; formatted != want {
+		t.Errorf("formatted should be %q, got %q", want, formatted)
+	}
+}
+
+func TestFormatDirectives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "typewriter")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"a.go": `package a
+
+// Thing is a thing.
+//+test  * foo:"bar, baz"   qux
+type Thing struct {
+	// +test bar
+	Name string //   +test   foo
+}
+
+// +test foo
+type Other int
+`,
+		"b.go": `package a
+
+// +test foo:"bar"
+type Canonical int
+
+// +test foo:"&"
+type Broken int
+`,
+	}
+
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Chdir(wd)
+
+	unformatted, err := UnformattedDirectives("+test")
+
+	if len(unformatted) != 1 || unformatted[0] != "a.go" || err == nil {
+		t.Errorf("only a.go should be unformatted, with an error in b.go, got %v and %v", unformatted, err)
+	}
+
+	if b, _ := ioutil.ReadFile("a.go"); string(b) != files["a.go"] {
+		t.Errorf("listing unformatted files should not rewrite them")
+	}
+
+	written, err := FormatDirectives("+test")
+
+	if errs, ok := err.(ErrorList); !ok || len(errs) != 1 || errs[0].Pos.Filename != "b.go" || errs[0].Pos.Line != 6 {
+		t.Errorf("should have returned the error in b.go, got %v", err)
+	}
+
+	if len(written) != 1 || written[0] != "a.go" {
+		t.Errorf("only a.go should have been written, got %v", written)
+	}
+
+	b, err := ioutil.ReadFile("a.go")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `package a
+
+// Thing is a thing.
+// +test * foo:"bar,baz" qux
+type Thing struct {
+	// +test bar
+	Name string // +test foo
+}
+
+// +test foo
+type Other int
+`
+
+	if string(b) != want {
+		t.Errorf("a.go should be\n%s\ngot\n%s", want, b)
+	}
+}
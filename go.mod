@@ -1,5 +1,5 @@
 module github.com/clipperhouse/typewriter
 
-go 1.13
+go 1.23
 
 require golang.org/x/tools v0.0.0-20200107050322-53017a39ae36
@@ -1,5 +1,13 @@
 package typewriter
 
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
 // ImportSpec describes the name and path of an import.
 // The name is often omitted.
 //
@@ -7,3 +15,154 @@ package typewriter
 type ImportSpec struct {
 	Name, Path string
 }
+
+// name returns the name by which the import is referred to. If it is omitted, the name is guessed from the path,
+// eg template for text/template, or yaml for gopkg.in/yaml.v2.
+func (imp ImportSpec) name() string {
+	if imp.Name != "" {
+		return imp.Name
+	}
+
+	elems := strings.Split(imp.Path, "/")
+	name := elems[len(elems)-1]
+
+	// a major version, eg github.com/x/y/v2, follows the name
+	if len(elems) > 1 && isMajorVersion(name) {
+		name = elems[len(elems)-2]
+	}
+
+	// or is appended to it, eg gopkg.in/yaml.v2
+	if i := strings.LastIndex(name, "."); i > 0 && isMajorVersion(name[i+1:]) {
+		name = name[:i]
+	}
+
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return -1
+		}
+		return r
+	}, name)
+
+	return name
+}
+
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, r := range s[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ImportPlan resolves imports such that each path is imported once, under a name which no other import takes.
+// Imports are planned in the order in which they are added: a later import of a path already planned is omitted,
+// and a later import whose name is taken is given a unique alias, eg template2.
+type ImportPlan struct {
+	imports []ImportSpec
+	paths   map[string]int    // index of the import of each path
+	names   map[string]string // path of the import of each name
+}
+
+// NewImportPlan creates a plan for the imports, in order.
+func NewImportPlan(imports ...ImportSpec) *ImportPlan {
+	p := &ImportPlan{
+		paths: make(map[string]int),
+		names: make(map[string]string),
+	}
+	for _, imp := range imports {
+		p.Add(imp)
+	}
+	return p
+}
+
+// Plan creates a plan for the imports of the set, in order of path. Of imports of the same path, one without a
+// name is preferred.
+func (set ImportSpecSet) Plan() *ImportPlan {
+	imports := set.ToSlice()
+	sort.Slice(imports, func(i, j int) bool {
+		if imports[i].Path != imports[j].Path {
+			return imports[i].Path < imports[j].Path
+		}
+		return imports[i].Name < imports[j].Name
+	})
+	return NewImportPlan(imports...)
+}
+
+// Add plans the import, and returns the name by which its path is referred to.
+func (p *ImportPlan) Add(imp ImportSpec) string {
+	if i, ok := p.paths[imp.Path]; ok {
+		// a blank import, for side effects, gives way to a named one
+		if p.imports[i].Name == "_" && imp.Name != "_" {
+			p.imports[i] = p.unique(imp)
+		}
+		return p.imports[i].name()
+	}
+
+	imp = p.unique(imp)
+
+	p.paths[imp.Path] = len(p.imports)
+	p.imports = append(p.imports, imp)
+
+	return imp.name()
+}
+
+// unique aliases the import if its name is taken, and takes the name
+func (p *ImportPlan) unique(imp ImportSpec) ImportSpec {
+	name := imp.name()
+	if name == "_" || name == "." {
+		return imp
+	}
+
+	base := name
+	if !token.IsIdentifier(base) {
+		base = "pkg"
+	}
+
+	for i := 2; p.names[name] != "" && p.names[name] != imp.Path; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+
+	if name != imp.name() {
+		imp.Name = name
+	}
+
+	p.names[name] = imp.Path
+	return imp
+}
+
+// Imports returns the planned imports, in the order in which they were added.
+func (p *ImportPlan) Imports() []ImportSpec {
+	return p.imports
+}
+
+// Name returns the name by which the planned import of the path is referred to, or "" if the path is not planned.
+func (p *ImportPlan) Name(path string) string {
+	i, ok := p.paths[path]
+	if !ok {
+		return ""
+	}
+	return p.imports[i].name()
+}
+
+// Qualifier qualifies types by the names of their planned imports, for use with types.TypeString. Types of the
+// package local, or of a dot import, are not qualified; those of packages not planned are qualified by package name.
+func (p *ImportPlan) Qualifier(local *types.Package) types.Qualifier {
+	return func(other *types.Package) string {
+		if local != nil && other.Path() == local.Path() {
+			return ""
+		}
+		switch name := p.Name(other.Path()); name {
+		case "", "_":
+			return other.Name()
+		case ".":
+			return ""
+		default:
+			return name
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package typewriter
+
+import (
+	"go/types"
+	"testing"
+)
+
+func TestImportSpecName(t *testing.T) {
+	tests := []struct {
+		imp  ImportSpec
+		name string
+	}{
+		{ImportSpec{Path: "fmt"}, "fmt"},
+		{ImportSpec{Path: "text/template"}, "template"},
+		{ImportSpec{Name: "tmpl", Path: "text/template"}, "tmpl"},
+		{ImportSpec{Path: "github.com/x/y/v2"}, "y"},
+		{ImportSpec{Path: "gopkg.in/yaml.v2"}, "yaml"},
+		{ImportSpec{Path: "github.com/mattn/go-sqlite3"}, "sqlite3"},
+		{ImportSpec{Path: "github.com/x/go-y.z"}, "yz"},
+	}
+
+	for _, test := range tests {
+		if name := test.imp.name(); name != test.name {
+			t.Errorf("name of %v should be %q, got %q", test.imp, test.name, name)
+		}
+	}
+}
+
+func TestImportPlan(t *testing.T) {
+	plan := NewImportPlan(
+		ImportSpec{Path: "fmt"},
+		ImportSpec{Name: "f", Path: "fmt"},
+		ImportSpec{Path: "html/template"},
+		ImportSpec{Path: "text/template"},
+		ImportSpec{Name: "_", Path: "image/png"},
+		ImportSpec{Path: "image/png"},
+		ImportSpec{Name: "template2", Path: "github.com/x/template2"},
+		ImportSpec{Path: "github.com/x/template"},
+		ImportSpec{Name: ".", Path: "github.com/x/dot"},
+	)
+
+	expected := []ImportSpec{
+		{Path: "fmt"},
+		{Path: "html/template"},
+		{Name: "template2", Path: "text/template"},
+		{Path: "image/png"},
+		{Name: "template22", Path: "github.com/x/template2"},
+		{Name: "template3", Path: "github.com/x/template"},
+		{Name: ".", Path: "github.com/x/dot"},
+	}
+
+	imports := plan.Imports()
+
+	if len(imports) != len(expected) {
+		t.Fatalf("imports should be %v, got %v", expected, imports)
+	}
+
+	for i, imp := range expected {
+		if imports[i] != imp {
+			t.Errorf("import %v should be %v, got %v", i, imp, imports[i])
+		}
+	}
+
+	names := map[string]string{
+		"fmt":           "fmt",
+		"text/template": "template2",
+		"image/png":     "png",
+		"net/http":      "",
+	}
+
+	for path, name := range names {
+		if plan.Name(path) != name {
+			t.Errorf("name of %s should be %q, got %q", path, name, plan.Name(path))
+		}
+	}
+
+	if name := plan.Add(ImportSpec{Path: "text/template"}); name != "template2" {
+		t.Errorf("adding a planned path should return its name, got %q", name)
+	}
+
+	local := types.NewPackage("github.com/x/local", "local")
+	q := plan.Qualifier(local)
+
+	for pkg, name := range map[*types.Package]string{
+		local: "",
+		types.NewPackage("text/template", "template"): "template2",
+		types.NewPackage("github.com/x/dot", "dot"):   "",
+		types.NewPackage("net/http", "http"):          "http",
+		types.NewPackage("html/template", "template"): "template",
+		types.NewPackage("gopkg.in/yaml.v2", "yaml"):  "yaml",
+	} {
+		if q(pkg) != name {
+			t.Errorf("qualifier of %s should be %q, got %q", pkg.Path(), name, q(pkg))
+		}
+	}
+}
+
+func TestImportSpecSetPlan(t *testing.T) {
+	set := NewImportSpecSet(
+		ImportSpec{Path: "text/template"},
+		ImportSpec{Name: "t", Path: "text/template"},
+		ImportSpec{Path: "html/template"},
+		ImportSpec{Path: "fmt"},
+	)
+
+	expected := []ImportSpec{
+		{Path: "fmt"},
+		{Path: "html/template"},
+		{Name: "template2", Path: "text/template"},
+	}
+
+	imports := set.Plan().Imports()
+
+	if len(imports) != len(expected) {
+		t.Fatalf("imports should be %v, got %v", expected, imports)
+	}
+
+	for i, imp := range expected {
+		if imports[i] != imp {
+			t.Errorf("import %v should be %v, got %v", i, imp, imports[i])
+		}
+	}
+}
@@ -17,3 +17,12 @@ type Interface interface {
 	// Write writes to the body of the generated code, following package declaration and imports.
 	Write(w io.Writer, t Type) error
 }
+
+// TargetWriter is an optional interface for typewriters which generate code from annotated funcs, consts and vars. See Target.
+type TargetWriter interface {
+	Interface
+	// TargetImports is a slice of imports required for the target; each will be written into the imports declaration.
+	TargetImports(t Target) []ImportSpec
+	// WriteTarget writes to the body of the generated code, following package declaration and imports.
+	WriteTarget(w io.Writer, t Target) error
+}
@@ -8,9 +8,9 @@ import (
 	"fmt"
 )
 
-const _itemType_name = "itemErroritemCommentPrefixitemDirectiveitemPointeritemTagitemColonQuoteitemMinusitemTagValueitemTypeParameteritemCloseQuoteitemEOF"
+const _itemType_name = "itemErroritemCommentPrefixitemDirectiveitemPointeritemTagitemColonQuoteitemMinusitemPlusitemTagValueitemTypeParameteritemCloseQuoteitemEOF"
 
-var _itemType_index = [...]uint8{0, 9, 26, 39, 50, 57, 71, 80, 92, 109, 123, 130}
+var _itemType_index = [...]uint8{0, 9, 26, 39, 50, 57, 71, 80, 88, 100, 117, 131, 138}
 
 func (i itemType) String() string {
 	if i < 0 || i+1 >= itemType(len(_itemType_index)) {
@@ -9,6 +9,7 @@ package typewriter
 import (
 	"fmt"
 	"go/token"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 )
@@ -32,6 +33,7 @@ const (
 	itemTag
 	itemColonQuote
 	itemMinus
+	itemPlus
 	itemTagValue
 	itemTypeParameter
 	itemCloseQuote
@@ -43,16 +45,20 @@ const eof = -1
 // stateFn represents the state of the scanner as a function that returns the next state.
 type stateFn func(*lexer) stateFn
 
-// lexer holds the state of the scanner.
+// lexer holds the state of the scanner. It is pull-based: state functions run only as
+// nextItem requires items, so an abandoned lexer is simply garbage collected.
 type lexer struct {
 	input        string    // the string being scanned
-	state        stateFn   // the next lexing function to enter
+	directive    string    // the directive, recognized following the comment prefix if of the form name:
+	state        stateFn   // the next lexing function to enter; nil when the scan is complete
 	pos          token.Pos // current position in the input
 	start        token.Pos // start position of this item
 	width        int       // width of last rune read from input
 	lastPos      token.Pos // position of most recent item returned by nextItem
-	items        chan item // channel of scanned items
+	items        []item    // scanned items; those from head onward have not been returned by nextItem
+	head         int
 	bracketDepth int
+	inQuote      bool // within the quoted values of a tag
 }
 
 // next returns the next rune in the input.
@@ -81,7 +87,13 @@ func (l *lexer) backup() {
 
 // emit passes an item back to the client.
 func (l *lexer) emit(t itemType) {
-	l.items <- item{t, l.start, l.input[l.start:l.pos]}
+	switch t {
+	case itemColonQuote:
+		l.inQuote = true
+	case itemCloseQuote:
+		l.inQuote = false
+	}
+	l.items = append(l.items, item{t, l.start, l.input[l.start:l.pos]})
 	l.start = l.pos
 }
 
@@ -90,34 +102,38 @@ func (l *lexer) ignore() {
 	l.start = l.pos
 }
 
-// errorf returns an error token and terminates the scan by passing
-// back a nil pointer that will be the next state, terminating l.nextItem.
+// errorf returns an error token and continues the scan at the next tag, see lexRecover.
 func (l *lexer) errorf(format string, args ...interface{}) stateFn {
-	l.items <- item{itemError, l.pos, fmt.Sprintf(format, args...)}
-	return nil
+	l.items = append(l.items, item{itemError, l.pos, fmt.Sprintf(format, args...)})
+	return lexRecover
 }
 
-// nextItem returns the next item from the input.
+// nextItem returns the next item from the input, running the state machine until one is available.
+// Once the input is exhausted, it continues to return itemEOF.
 func (l *lexer) nextItem() item {
-	item := <-l.items
+	for l.head == len(l.items) {
+		if l.state == nil {
+			return item{itemEOF, l.pos, ""}
+		}
+		// all items have been returned; reuse the buffer
+		l.items = l.items[:0]
+		l.head = 0
+		l.state = l.state(l)
+	}
+
+	item := l.items[l.head]
+	l.head++
 	l.lastPos = item.pos
 	return item
 }
 
-// lex creates a new scanner for the input string.
-func lex(input string) *lexer {
-	l := &lexer{
-		input: input,
-		items: make(chan item),
-	}
-	go l.run()
-	return l
-}
-
-// run runs the state machine for the lexer.
-func (l *lexer) run() {
-	for l.state = lexComment; l.state != nil; {
-		l.state = l.state(l)
+// lex creates a new scanner for the input string, a comment annotated with the directive.
+func lex(input, directive string) *lexer {
+	return &lexer{
+		input:     input,
+		directive: directive,
+		state:     lexComment,
+		items:     make([]item, 0, 2),
 	}
 }
 
@@ -152,6 +168,29 @@ Loop:
 	return nil
 }
 
+// lexRecover skips the remainder of a tag following an error, so that subsequent tags may be scanned,
+// and errors in them reported. The tag ends at the first space outside of quotes.
+func lexRecover(l *lexer) stateFn {
+	quoted := l.inQuote
+Loop:
+	for {
+		switch r := l.next(); {
+		case r == eof:
+			break Loop
+		case r == '"':
+			quoted = !quoted
+		case isSpace(r) && !quoted:
+			l.backup()
+			break Loop
+		}
+	}
+
+	l.ignore()
+	l.inQuote = false
+	l.bracketDepth = 0
+	return lexComment
+}
+
 // lexTag scans the elements inside quotes
 func lexTag(l *lexer) stateFn {
 	for {
@@ -179,6 +218,8 @@ func lexTagValues(l *lexer) stateFn {
 		switch r := l.next(); {
 		case r == '-':
 			l.emit(itemMinus)
+		case r == '+':
+			l.emit(itemPlus)
 		case isIdentifierPrefix(r):
 			return lexIdentifier(l, itemTagValue)
 		case r == '[':
@@ -207,7 +248,8 @@ func lexTypeParameters(l *lexer) stateFn {
 		switch r := l.next(); {
 		case r == ']':
 			if l.bracketDepth == 0 {
-				// closing bracket of type parameter
+				// closing bracket of type parameter; parser has no use for it
+				l.ignore()
 				return lexTagValues
 			}
 			return l.errorf("additional close bracket")
@@ -217,7 +259,8 @@ func lexTypeParameters(l *lexer) stateFn {
 		case isSpace(r) || r == ',':
 			l.ignore()
 		case r == '"':
-			// premature end
+			// premature end; leave the quote to close the values
+			l.backup()
 			return l.errorf("expected close bracket")
 		default:
 			return l.errorf("illegal character '%s' in type parameter", string(r))
@@ -265,6 +308,13 @@ func lexCommentPrefix(l *lexer) stateFn {
 		l.next()
 	}
 	l.emit(itemCommentPrefix)
+
+	// a directive of the form name: immediately follows the slashes, eg //gen:slice
+	if goStyle(l.directive) && strings.HasPrefix(l.input[l.pos:], l.directive) {
+		l.pos += token.Pos(len(l.directive))
+		l.emit(itemDirective)
+	}
+
 	return lexComment
 }
 
@@ -283,6 +333,9 @@ Loop:
 		switch r := l.next(); {
 		case isAlphaNumeric(r):
 			// absorb.
+		case typ == itemTagValue && (r == '.' || r == '/'):
+			// a qualified value, such as an import path
+			return lexQualifiedValue
 		default:
 			if !isTerminator(r) {
 				return l.errorf("illegal character '%c' in identifier", r)
@@ -305,6 +358,23 @@ Loop:
 	}
 }
 
+// lexQualifiedValue scans the remainder of a tag value containing dots or slashes, eg github.com/x/pb.User
+func lexQualifiedValue(l *lexer) stateFn {
+	for {
+		switch r := l.next(); {
+		case isAlphaNumeric(r) || r == '.' || r == '/' || r == '-':
+			// absorb
+		case isTerminator(r):
+			// once we get here, we've absorbed the delimiter; backup as not to emit it
+			l.backup()
+			l.emit(itemTagValue)
+			return lexTagValues
+		default:
+			return l.errorf("illegal character '%c' in qualified value", r)
+		}
+	}
+}
+
 func isTerminator(r rune) bool {
 	if isSpace(r) || isEndOfLine(r) {
 		return true
@@ -336,8 +406,9 @@ func isIdentifierPrefix(r rune) bool {
 	return r == '_' || unicode.IsLetter(r)
 }
 
-// isTypeDecl reports whether r a character legal in a type declaration, eg map[*Thing]interface{}
+// isTypeDecl reports whether r a character legal in a type declaration, eg map[*Thing]interface{}, or one which
+// refers to another package by name or import path, eg time.Time or github.com/x/pb-go.User
 // brackets are a special case, handled in lexTypeParameter
 func isTypeDecl(r rune) bool {
-	return r == '*' || r == '{' || r == '}' || r == '[' || r == ']' || isAlphaNumeric(r)
+	return r == '*' || r == '{' || r == '}' || r == '[' || r == ']' || r == '.' || r == '/' || r == '-' || isAlphaNumeric(r)
 }
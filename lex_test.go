@@ -0,0 +1,54 @@
+package typewriter
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func FuzzLex(f *testing.F) {
+	for _, test := range parseTests {
+		f.Add(test.comment)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		before := runtime.NumGoroutine()
+
+		l := lex(input, "+test")
+
+		// every state consumes input or emits an item, so the number of items is bounded by the input
+		limit := 2*len(input) + 2
+		var last item
+
+		for i := 0; ; i++ {
+			if i > limit {
+				t.Fatalf("lexer did not terminate after %v items for %q", i, input)
+			}
+
+			item := l.nextItem()
+
+			if item.pos < last.pos || int(item.pos) > len(input) {
+				t.Fatalf("item %v at %v out of order or out of range, following %v at %v, for %q", item.typ, item.pos, last.typ, last.pos, input)
+			}
+
+			// the value of an error item is its message, otherwise it's the scanned input
+			if item.typ != itemError && !strings.HasPrefix(input[item.pos:], item.val) {
+				t.Fatalf("item %v %q is not found at %v in %q", item.typ, item.val, item.pos, input)
+			}
+
+			last = item
+
+			if item.typ == itemEOF {
+				break
+			}
+		}
+
+		if l.nextItem().typ != itemEOF {
+			t.Fatalf("lexer should continue to return EOF for %q", input)
+		}
+
+		if after := runtime.NumGoroutine(); after > before {
+			t.Fatalf("lexer should not start goroutines; had %v, now %v", before, after)
+		}
+	})
+}
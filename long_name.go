@@ -0,0 +1,217 @@
+package typewriter
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LongName provides a name that may be useful for generated names, eg map[string]*Foo becomes MapString_PointerFoo.
+// The parts of lists are separated by underscores, and nested funcs, structs and interfaces are closed by End.
+func (t Type) LongName() string {
+	var b strings.Builder
+
+	if t.Type == nil {
+		longNameOf(&b, t.Name)
+		return b.String()
+	}
+
+	typ := t.Type
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+
+	var local *types.Package
+	if t.source != nil {
+		local = t.source.Package
+	}
+
+	longName(&b, typ, local, true)
+	return b.String()
+}
+
+// longName writes the parts of the name of typ; types from packages other than local are qualified by package name.
+// Types of variable length are closed unless they are last, ie nothing follows them in the name.
+func longName(b *strings.Builder, typ types.Type, local *types.Package, last bool) {
+	// separate writes the separator preceding all but the first part of a list
+	separate := func(i int) {
+		if i > 0 {
+			b.WriteString("_")
+		}
+	}
+
+	// end closes a type of variable length
+	end := func() {
+		if !last {
+			b.WriteString("End")
+		}
+	}
+
+	// qualified writes the name of a declared type, and its type arguments, if any
+	qualified := func(obj types.Object, args *types.TypeList) {
+		if pkg := obj.Pkg(); pkg != nil && local != nil && pkg.Path() != local.Path() {
+			b.WriteString(title(pkg.Name()))
+		}
+		b.WriteString(title(obj.Name()))
+		for i := 0; i < args.Len(); i++ {
+			separate(i)
+			longName(b, args.At(i), local, last && i == args.Len()-1)
+		}
+	}
+
+	// tuple writes the types of parameters or results, the last of which might end the name
+	tuple := func(t *types.Tuple, final bool) {
+		for i := 0; i < t.Len(); i++ {
+			separate(i)
+			longName(b, t.At(i).Type(), local, final && i == t.Len()-1)
+		}
+	}
+
+	switch x := typ.(type) {
+	case *types.Basic:
+		if x.Kind() == types.UnsafePointer {
+			b.WriteString("Unsafe")
+		}
+		b.WriteString(title(x.Name()))
+	case *types.Named:
+		qualified(x.Obj(), x.TypeArgs())
+	case *types.Alias:
+		qualified(x.Obj(), x.TypeArgs())
+	case *types.TypeParam:
+		b.WriteString(title(x.Obj().Name()))
+	case *types.Pointer:
+		b.WriteString("Pointer")
+		longName(b, x.Elem(), local, last)
+	case *types.Slice:
+		b.WriteString("Slice")
+		longName(b, x.Elem(), local, last)
+	case *types.Array:
+		fmt.Fprintf(b, "Array%d", x.Len())
+		longName(b, x.Elem(), local, last)
+	case *types.Map:
+		b.WriteString("Map")
+		longName(b, x.Key(), local, false)
+		separate(1)
+		longName(b, x.Elem(), local, last)
+	case *types.Chan:
+		switch x.Dir() {
+		case types.SendOnly:
+			b.WriteString("Send")
+		case types.RecvOnly:
+			b.WriteString("Receive")
+		}
+		b.WriteString("Chan")
+		longName(b, x.Elem(), local, last)
+	case *types.Signature:
+		b.WriteString("Func")
+		results := x.Results().Len() > 0
+		tuple(x.Params(), last && !results && !x.Variadic())
+		if x.Variadic() {
+			b.WriteString("Variadic")
+		}
+		if results {
+			b.WriteString("Returns")
+			tuple(x.Results(), last)
+		}
+		end()
+	case *types.Struct:
+		b.WriteString("Struct")
+		for i := 0; i < x.NumFields(); i++ {
+			f := x.Field(i)
+			separate(i)
+			if !f.Embedded() {
+				b.WriteString(title(f.Name()))
+			}
+			longName(b, f.Type(), local, last && i == x.NumFields()-1)
+		}
+		end()
+	case *types.Interface:
+		b.WriteString("Interface")
+		for i := 0; i < x.NumEmbeddeds(); i++ {
+			separate(i)
+			longName(b, x.EmbeddedType(i), local, last && i == x.NumEmbeddeds()-1 && x.NumExplicitMethods() == 0)
+		}
+		for i := 0; i < x.NumExplicitMethods(); i++ {
+			separate(x.NumEmbeddeds() + i)
+			b.WriteString(title(x.ExplicitMethod(i).Name()))
+		}
+		end()
+	case *types.Union:
+		for i := 0; i < x.Len(); i++ {
+			if i > 0 {
+				b.WriteString("Or")
+			}
+			if x.Term(i).Tilde() {
+				b.WriteString("Tilde")
+			}
+			longName(b, x.Term(i).Type(), local, last && i == x.Len()-1)
+		}
+		end()
+	default:
+		b.WriteString(title(identifier(typ.String())))
+	}
+}
+
+// longNameOf writes the parts of the name of a type which has not been evaluated, by its tokens, without the separators of evaluated types
+func longNameOf(b *strings.Builder, name string) {
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	src := []byte(name)
+	s.Init(fset.AddFile("", -1, len(src)), src, nil, 0)
+
+	var parts []string
+	prev := token.ILLEGAL
+
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		switch tok {
+		case token.MUL:
+			if prev != token.ILLEGAL { // a pointer to the type itself is not described
+				parts = append(parts, "Pointer")
+			}
+		case token.RBRACK:
+			if prev == token.LBRACK {
+				parts = append(parts, "Slice")
+			}
+		case token.INT:
+			if prev == token.LBRACK {
+				parts = append(parts, "Array"+lit)
+			}
+		case token.IDENT:
+			parts = append(parts, title(lit))
+		case token.MAP, token.CHAN, token.FUNC, token.STRUCT, token.INTERFACE:
+			parts = append(parts, title(tok.String()))
+		}
+
+		prev = tok
+	}
+
+	b.WriteString(strings.Join(parts, ""))
+}
+
+// title upper-cases the first letter of s
+func title(s string) string {
+	if s == "" {
+		return s
+	}
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+// identifier removes the characters of s which are not valid in an identifier
+func identifier(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
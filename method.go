@@ -0,0 +1,92 @@
+package typewriter
+
+import (
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// Method describes a method in the method set of a type.
+type Method struct {
+	Name      string
+	Signature string // as it would be written in an interface, relative to the type's package, eg Less(o Value) bool
+	Pointer   bool   // declared with a pointer receiver, and so in the method set of the pointer type only
+	Exported  bool
+	Promoted  bool // from an embedded field
+	Position  token.Position
+}
+
+func (m Method) String() string {
+	return m.Signature
+}
+
+// Methods returns the method set of the type, ordered by name: methods with value receivers, and those promoted
+// from embedded fields, less the unexported methods of other packages. For methods which may be called on an
+// addressable value of the type, see PointerMethods.
+func (t Type) Methods() []Method {
+	if t.Type == nil {
+		return nil
+	}
+	return t.source.methods(t.Type)
+}
+
+// PointerMethods returns the method set of a pointer to the type, ordered by name, which includes methods with either
+// value or pointer receivers. If the type is itself a pointer, it is the same as Methods.
+func (t Type) PointerMethods() []Method {
+	if t.Type == nil {
+		return nil
+	}
+	if _, ok := t.Type.(*types.Pointer); ok {
+		return t.Methods()
+	}
+	return t.source.methods(types.NewPointer(t.Type))
+}
+
+// HasMethod reports whether the method set of the type includes a method of the name, eg {{if .HasMethod "String"}}.
+// Methods with pointer receivers are not included, see PointerMethods.
+func (t Type) HasMethod(name string) bool {
+	for _, m := range t.Methods() {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Package) methods(typ types.Type) []Method {
+	var result []Method
+
+	ms := types.NewMethodSet(typ)
+	for i := 0; i < ms.Len(); i++ {
+		sel := ms.At(i)
+		fn := sel.Obj().(*types.Func)
+		sig := fn.Type().(*types.Signature)
+
+		// unexported methods of other packages, eg those promoted from an embedded *strings.Builder, can't be called
+		if !fn.Exported() && (p == nil || p.Package == nil || fn.Pkg().Path() != p.Path()) {
+			continue
+		}
+
+		m := Method{
+			Name:      fn.Name(),
+			Signature: fn.Name() + strings.TrimPrefix(types.TypeString(sig, p.relative()), "func"),
+			Exported:  fn.Exported(),
+			Promoted:  len(sel.Index()) > 1,
+			Position:  p.position(fn.Pos()),
+		}
+
+		if recv := sig.Recv(); recv != nil {
+			_, m.Pointer = recv.Type().(*types.Pointer)
+		}
+
+		result = append(result, m)
+	}
+
+	// a method set is ordered by Id, which qualifies unexported names by their package; the remaining names are unique
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
@@ -0,0 +1,120 @@
+package typewriter
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestMethods(t *testing.T) {
+	p, _ := testPackage(t, `package methods
+
+import (
+	"io"
+	"strings"
+)
+
+type Value struct {
+	Base
+}
+
+func (v Value) String() string                         { return "" }
+func (v *Value) Set(s string)                          {}
+func (v Value) WriteTo(w io.Writer) (n int64, err error) { return 0, nil }
+func (v Value) less(o Value) bool                      { return false }
+
+type Base struct{}
+
+func (b Base) ID() int { return 0 }
+
+type Reader interface {
+	io.Reader
+}
+
+type Builder struct {
+	*strings.Builder
+}
+
+func (b Builder) less() bool { return false }
+`)
+
+	tests := []struct {
+		typ     string
+		methods []string
+		pointer []string
+	}{
+		{"Value",
+			[]string{"ID() int", "String() string", "WriteTo(w io.Writer) (n int64, err error)", "less(o Value) bool"},
+			[]string{"ID() int", "Set(s string)", "String() string", "WriteTo(w io.Writer) (n int64, err error)", "less(o Value) bool"},
+		},
+		{"*Value",
+			[]string{"ID() int", "Set(s string)", "String() string", "WriteTo(w io.Writer) (n int64, err error)", "less(o Value) bool"},
+			[]string{"ID() int", "Set(s string)", "String() string", "WriteTo(w io.Writer) (n int64, err error)", "less(o Value) bool"},
+		},
+		{"Reader",
+			[]string{"Read(p []byte) (n int, err error)"},
+			nil,
+		},
+		{"int", nil, nil},
+	}
+
+	for i, test := range tests {
+		typ, err := p.Eval(test.typ)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := signatures(typ.Methods()); got != strings.Join(test.methods, "; ") {
+			t.Errorf("[test %v] methods of %s should have been %q, got %q", i, test.typ, strings.Join(test.methods, "; "), got)
+		}
+
+		if got := signatures(typ.PointerMethods()); got != strings.Join(test.pointer, "; ") {
+			t.Errorf("[test %v] pointer methods of %s should have been %q, got %q", i, test.typ, strings.Join(test.pointer, "; "), got)
+		}
+	}
+
+	value, _ := p.Eval("Value")
+
+	if !value.HasMethod("String") || !value.HasMethod("ID") {
+		t.Errorf("Value should have methods String and ID")
+	}
+
+	if value.HasMethod("Set") {
+		t.Errorf("Value should not have method Set, which has a pointer receiver")
+	}
+
+	set := value.PointerMethods()[1]
+
+	if set.Name != "Set" || !set.Pointer || !set.Exported || set.Promoted || set.Position.Line != 13 {
+		t.Errorf("unexpected method %+v", set)
+	}
+
+	if id := value.Methods()[0]; !id.Promoted || id.Pointer {
+		t.Errorf("ID should be promoted from Base, got %+v", id)
+	}
+
+	// unexported methods of other packages, which can't be called, are left out; the rest are ordered by name
+	builder, _ := p.Eval("Builder")
+
+	var names []string
+	for _, m := range builder.Methods() {
+		names = append(names, m.Name)
+	}
+
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("methods of Builder should be ordered by name, got %v", names)
+	}
+
+	if !builder.HasMethod("less") || builder.HasMethod("copyCheck") || builder.HasMethod("grow") {
+		t.Errorf("Builder should have its own unexported method, and not those of strings.Builder, got %v", names)
+	}
+}
+
+func signatures(methods []Method) string {
+	var s []string
+	for _, m := range methods {
+		s = append(s, m.String())
+	}
+	return strings.Join(s, "; ")
+}
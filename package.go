@@ -5,6 +5,8 @@ import (
 	"go/ast"
 	"go/token"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	// gcimporter implements Import for gc-generated files
 	"go/importer"
@@ -17,16 +19,32 @@ type evaluator interface {
 
 func NewPackage(path, name string) *Package {
 	return &Package{
-		types.NewPackage(path, name),
-		token.NewFileSet(),
-		[]Type{},
+		Package:  types.NewPackage(path, name),
+		fset:     token.NewFileSet(),
+		info:     newInfo(),
+		importer: importer.Default(),
+		Types:    []Type{},
+		Targets:  []Target{},
 	}
 }
 
 type Package struct {
 	*types.Package
-	fset  *token.FileSet
-	Types []Type
+	fset     *token.FileSet
+	info     *types.Info
+	importer types.Importer
+	Types    []Type
+	Targets  []Target
+	// constraint expressions, as type-checked by evalConstraint
+	constraints map[string]*types.Interface
+	// directives of the App, see docText
+	directives []string
+}
+
+func newInfo() *types.Info {
+	return &types.Info{
+		Defs: make(map[*ast.Ident]types.Object),
+	}
 }
 
 type TypeCheckError struct {
@@ -42,11 +60,18 @@ func (t *TypeCheckError) Error() string {
 	return result + t.err.Error()
 }
 
+// Unwrap returns the underlying error, which is an *Error once a position has been added.
+func (t *TypeCheckError) Unwrap() error {
+	return t.err
+}
+
 func (t *TypeCheckError) addPos(fset *token.FileSet, pos token.Pos) {
 	// some errors come with empty pos
-	err := strings.TrimLeft(t.err.Error(), ":- ")
-	// prepend position information (file name, line, column)
-	t.err = fmt.Errorf("%s: %s", fset.Position(pos), err)
+	err := newError(fset.Position(pos), CodeTypeCheck, "%s", strings.TrimLeft(t.err.Error(), ":- "))
+	if t.ignored {
+		err.Severity = SeverityWarning
+	}
+	t.err = err
 }
 
 func combine(ts []*TypeCheckError) error {
@@ -68,10 +93,12 @@ func getPackage(fset *token.FileSet, a *ast.Package, conf *Config) (*Package, *T
 		files = append(files, f)
 	}
 
+	imp := importer.Default()
+
 	config := types.Config{
 		DisableUnusedImportCheck: true,
 		IgnoreFuncBodies:         true,
-		Importer:                 importer.Default(),
+		Importer:                 imp,
 	}
 
 	if conf.IgnoreTypeCheckErrors {
@@ -80,9 +107,17 @@ func getPackage(fset *token.FileSet, a *ast.Package, conf *Config) (*Package, *T
 		config.Error = func(err error) {}
 	}
 
-	typesPkg, err := config.Check(a.Name, fset, files, nil)
+	info := newInfo()
+	typesPkg, err := config.Check(a.Name, fset, files, info)
 
-	p := &Package{typesPkg, fset, []Type{}}
+	p := &Package{
+		Package:  typesPkg,
+		fset:     fset,
+		info:     info,
+		importer: imp,
+		Types:    []Type{},
+		Targets:  []Target{},
+	}
 
 	if err != nil {
 		return p, &TypeCheckError{err, conf.IgnoreTypeCheckErrors}
@@ -92,9 +127,14 @@ func getPackage(fset *token.FileSet, a *ast.Package, conf *Config) (*Package, *T
 }
 
 func (p *Package) Eval(name string) (Type, error) {
+	return p.eval(p.Package, name)
+}
+
+// eval evaluates a type expression in the scope of pkg, which is this package, or stands in for it, see evalIn
+func (p *Package) eval(pkg *types.Package, name string) (Type, error) {
 	var result Type
 
-	t, err := types.Eval(p.fset, p.Package, token.NoPos, name)
+	t, err := types.Eval(p.fset, pkg, token.NoPos, name)
 	if err != nil {
 		return result, err
 	}
@@ -103,14 +143,7 @@ func (p *Package) Eval(name string) (Type, error) {
 		return result, &TypeCheckError{err, false}
 	}
 
-	result = Type{
-		Pointer:    isPointer(t.Type),
-		Name:       strings.TrimLeft(name, Pointer(true).String()), // trims the * if it exists
-		comparable: isComparable(t.Type),
-		numeric:    isNumeric(t.Type),
-		ordered:    isOrdered(t.Type),
-		Type:       t.Type,
-	}
+	result = p.newType(t.Type, strings.TrimLeft(name, Pointer(true).String())) // trims the * if it exists
 
 	if isInvalid(t.Type) {
 		err := fmt.Errorf("invalid type: %s", name)
@@ -119,3 +152,235 @@ func (p *Package) Eval(name string) (Type, error) {
 
 	return result, nil
 }
+
+// evalIn evaluates a type expression as it would be written in the file which contains pos, such that it may refer
+// to the packages the file imports, by the names under which they are imported, eg time.Time. Other packages may
+// be referred to by import path, eg github.com/x/pb.User, or by the path of a standard package, eg io.Reader.
+func (p *Package) evalIn(pos token.Pos, expr string) (Type, error) {
+	// a stand-in for the package, with the names of the file scope, ie its imports
+	scope := types.NewPackage(p.Path(), p.Name())
+
+	inner := p.Scope().Innermost(pos)
+	if inner == nil {
+		inner = p.Scope()
+	}
+
+	for s := inner; s != nil && s != types.Universe; s = s.Parent() {
+		for _, name := range s.Names() {
+			obj := s.Lookup(name)
+
+			// the type checker requires that package names belong to the package being checked
+			if pn, ok := obj.(*types.PkgName); ok {
+				obj = types.NewPkgName(token.NoPos, scope, name, pn.Imported())
+			}
+
+			scope.Scope().Insert(obj) // inner scopes first, which shadow outer ones
+		}
+	}
+
+	qualified, imported, err := p.qualify(scope, expr)
+	if err != nil {
+		return Type{}, &TypeCheckError{err, false}
+	}
+
+	result, err := p.eval(scope, qualified)
+
+	// a package referred to by path is named for the package
+	if imported && result.Type != nil {
+		result.Name = p.typeOf(result.Type).Name
+	}
+
+	return result, err
+}
+
+// qualify rewrites the references to other packages in a type expression, eg the go/token of go/token.Pos, by the
+// names under which the packages are found in scope, importing those which are not; imported reports whether any were
+func (p *Package) qualify(scope *types.Package, expr string) (qualified string, imported bool, err error) {
+	var b strings.Builder
+
+	for i := 0; i < len(expr); {
+		// a run of characters which might be a qualified identifier
+		j := i
+		for j < len(expr) && isQualified(expr[j]) {
+			j++
+		}
+
+		if j == i {
+			b.WriteByte(expr[i])
+			i++
+			continue
+		}
+
+		run := expr[i:j]
+		i = j
+
+		k := strings.LastIndex(run, ".")
+		if k <= 0 {
+			b.WriteString(run)
+			continue
+		}
+		path, name := run[:k], run[k+1:]
+
+		// imported by the file, under this name
+		if _, ok := scope.Scope().Lookup(path).(*types.PkgName); ok {
+			b.WriteString(run)
+			continue
+		}
+
+		pkg, err := p.importPackage(path)
+		if err != nil {
+			return "", false, err
+		}
+
+		// the package is named as it declares itself, unless that name is taken
+		local := pkg.Name()
+		for n := 2; ; n++ {
+			obj := scope.Scope().Lookup(local)
+			if obj == nil {
+				scope.Scope().Insert(types.NewPkgName(token.NoPos, scope, local, pkg))
+				break
+			}
+			if pn, ok := obj.(*types.PkgName); ok && pn.Imported() == pkg {
+				break
+			}
+			local = fmt.Sprintf("%s%d", pkg.Name(), n)
+		}
+
+		b.WriteString(local + "." + name)
+		imported = true
+	}
+
+	return b.String(), imported, nil
+}
+
+// isQualified reports whether c might be part of a qualified identifier, eg github.com/x/pb-go.User
+func isQualified(c byte) bool {
+	return c == '.' || c == '/' || c == '-' || c == '_' || c >= utf8.RuneSelf || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+// evalTypeSpec evaluates a declared type; generic types are looked up rather than evaluated,
+// since a bare generic type is not a valid type expression
+func (p *Package) evalTypeSpec(pointer Pointer, s *ast.TypeSpec) (Type, error) {
+	if s.TypeParams == nil {
+		return p.Eval(pointer.String() + s.Name.Name)
+	}
+
+	var result Type
+
+	obj, ok := p.Scope().Lookup(s.Name.Name).(*types.TypeName)
+	if !ok {
+		err := fmt.Errorf("invalid type: %s", s.Name.Name)
+		return result, &TypeCheckError{err, false}
+	}
+
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		err := fmt.Errorf("invalid type: %s", s.Name.Name)
+		return result, &TypeCheckError{err, false}
+	}
+
+	var typ types.Type = named
+	if pointer {
+		typ = types.NewPointer(typ)
+	}
+
+	result = p.newType(typ, s.Name.Name)
+	result.TypeParams = newTypeParams(named.TypeParams(), p.Package)
+
+	return result, nil
+}
+
+// newType creates a Type from a types.Type, caching its predicates
+func (p *Package) newType(typ types.Type, name string) Type {
+	return Type{
+		source:     p,
+		Pointer:    isPointer(typ),
+		Name:       name,
+		comparable: isComparable(typ),
+		numeric:    isNumeric(typ),
+		ordered:    isOrdered(typ),
+		kinds:      kindsOf(typ),
+		Type:       typ,
+	}
+}
+
+// typeOf creates a Type from a types.Type, named as it would be written in this package, eg token.Position
+func (p *Package) typeOf(typ types.Type) Type {
+	return p.newType(typ, strings.TrimPrefix(types.TypeString(typ, p.relative()), Pointer(true).String()))
+}
+
+// relative qualifies types from other packages by package name, or by path if this package is unknown
+func (p *Package) relative() types.Qualifier {
+	if p == nil || p.Package == nil {
+		return nil
+	}
+	return qualifier(p.Package)
+}
+
+// position returns the position of pos in the files of the package, if known
+func (p *Package) position(pos token.Pos) token.Position {
+	if p == nil || p.fset == nil {
+		return token.Position{}
+	}
+	return p.fset.Position(pos)
+}
+
+// targetTag names the tag which annotates a type from another package, eg:
+//
+//	// +gen target:"github.com/x/pb.User" slice:"Where"
+//	type _ struct{}
+const targetTag = "target"
+
+// evalTarget evaluates a type from another package, given as import path and name
+func (p *Package) evalTarget(pointer Pointer, tag Tag) (Type, error) {
+	var result Type
+
+	if len(tag.Values) != 1 || tag.Negated {
+		return result, fmt.Errorf("%s tag requires a single type, eg %s:\"github.com/x/pb.User\"", targetTag, targetTag)
+	}
+
+	target := tag.Values[0].Name
+
+	i := strings.LastIndex(target, ".")
+	if i < strings.LastIndex(target, "/") || i <= 0 {
+		return result, fmt.Errorf("%s %q must be of the form import/path.Name", targetTag, target)
+	}
+	path, name := target[:i], target[i+1:]
+
+	pkg, err := p.importPackage(path)
+	if err != nil {
+		return result, err
+	}
+
+	obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok || !obj.Exported() {
+		return result, fmt.Errorf("%s is not an exported type in %q", name, path)
+	}
+
+	typ := obj.Type()
+	if pointer {
+		typ = types.NewPointer(typ)
+	}
+
+	return p.newType(typ, pkg.Name()+"."+name), nil
+}
+
+// importPackage returns the package for an import path, preferring the packages imported by this package
+func (p *Package) importPackage(path string) (*types.Package, error) {
+	if p == nil {
+		// the type was not evaluated in a package
+		return importer.Default().Import(path)
+	}
+
+	for _, imp := range p.Imports() {
+		if imp.Path() == path {
+			return imp, nil
+		}
+	}
+
+	if p.importer == nil {
+		p.importer = importer.Default()
+	}
+
+	return p.importer.Import(path)
+}
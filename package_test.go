@@ -1,6 +1,9 @@
 package typewriter
 
 import (
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"testing"
 
 	"go/types"
@@ -46,8 +49,8 @@ func TestEval(t *testing.T) {
 		t.Errorf("unable to assert %s as a *types.Struct", t1)
 	}
 
-	if tt1.NumFields() != 3 {
-		t.Errorf("%s should have 3 fields", tt1)
+	if tt1.NumFields() != 4 {
+		t.Errorf("%s should have 4 fields", tt1)
 	}
 
 	s2 := "*App"
@@ -103,3 +106,411 @@ func TestEval(t *testing.T) {
 		t.Error("'notreal' should not successfully evaluate as a type")
 	}
 }
+
+func TestEvalTarget(t *testing.T) {
+	a, err := NewApp("+test")
+
+	if err != nil {
+		t.Error(err)
+		return // we got problems, continuing will panic
+	}
+
+	p := a.Packages[0]
+
+	target := func(s string) Tag {
+		return Tag{Name: "target", Values: []TagValue{{Name: s}}}
+	}
+
+	t1, err1 := p.evalTarget(false, target("go/token.Position"))
+
+	if err1 != nil {
+		t.Fatal(err1)
+	}
+
+	if t1.Name != "token.Position" {
+		t.Errorf("name should be qualified by package name, got %q", t1.Name)
+	}
+
+	if t1.Pointer {
+		t.Errorf("%s is not a pointer type", t1)
+	}
+
+	if !t1.foreign(p) {
+		t.Errorf("%s should be foreign to package %s", t1, p.Name())
+	}
+
+	t2, err2 := p.evalTarget(true, target("go/token.Position"))
+
+	if err2 != nil {
+		t.Fatal(err2)
+	}
+
+	if !t2.Pointer || t2.String() != "*token.Position" {
+		t.Errorf("should have evaluated a pointer type, got %s", t2)
+	}
+
+	// imported by this package, so should be the identical type
+	t3, err3 := p.evalTarget(false, target("go/token.FileSet"))
+
+	if err3 != nil {
+		t.Fatal(err3)
+	}
+
+	var imported *types.Package
+	for _, imp := range p.Imports() {
+		if imp.Path() == "go/token" {
+			imported = imp
+		}
+	}
+
+	if imported == nil || t3.pkg() != imported {
+		t.Errorf("%s should be from the package imported by %s", t3, p.Name())
+	}
+
+	bad := []Tag{
+		target("go/token.notreal"),
+		target("go/token.Pos.String"),
+		target("go/notreal.Position"),
+		target("Position"),
+		target("go/token"),
+		{Name: "target"},
+		{Name: "target", Values: []TagValue{{Name: "go/token.Position"}, {Name: "go/token.Pos"}}},
+	}
+
+	for _, tag := range bad {
+		if _, err := p.evalTarget(false, tag); err == nil {
+			t.Errorf("%v should not successfully evaluate as a target", tag.Values)
+		}
+	}
+}
+
+// testPackage type-checks a single file of source, for declarations which can't live in this package
+func testPackage(t *testing.T, src string) (*Package, *ast.File) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "test.go", src, parser.ParseComments)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &ast.Package{
+		Name:  f.Name.Name,
+		Files: map[string]*ast.File{"test.go": f},
+	}
+
+	p, tcErr := getPackage(fset, a, DefaultConfig)
+
+	if tcErr != nil {
+		t.Fatal(tcErr)
+	}
+
+	return p, f
+}
+
+// typeSpec finds a type declaration by name
+func typeSpec(f *ast.File, name string) *ast.TypeSpec {
+	for _, d := range f.Decls {
+		if g, ok := d.(*ast.GenDecl); ok && g.Tok == token.TYPE {
+			for _, s := range g.Specs {
+				if s := s.(*ast.TypeSpec); s.Name.Name == name {
+					return s
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func TestEvalGeneric(t *testing.T) {
+	p, f := testPackage(t, `package generic
+
+import "go/ast"
+
+type Tree[T any] struct {
+	Left, Right *Tree[T]
+	Value       T
+}
+
+type Pair[K comparable, V interface{ ~int | ~string }] struct {
+	Key   K
+	Value V
+}
+
+type Nodes[N ast.Node] []N
+`)
+
+	t1, err1 := p.evalTypeSpec(false, typeSpec(f, "Tree"))
+
+	if err1 != nil {
+		t.Fatal(err1)
+	}
+
+	if !t1.Generic() || len(t1.TypeParams) != 1 {
+		t.Fatalf("%s should have 1 type parameter, found %v", t1, len(t1.TypeParams))
+	}
+
+	if t1.String() != "Tree[T]" || t1.TypeParamsDecl() != "[T any]" {
+		t.Errorf("unexpected rendering of %s: %s", t1.Name, t1.TypeParamsDecl())
+	}
+
+	t2, err2 := p.evalTypeSpec(true, typeSpec(f, "Pair"))
+
+	if err2 != nil {
+		t.Fatal(err2)
+	}
+
+	if t2.String() != "*Pair[K, V]" {
+		t.Errorf("expected *Pair[K, V], got %s", t2)
+	}
+
+	if decl := t2.TypeParamsDecl(); decl != "[K comparable, V interface{~int | ~string}]" {
+		t.Errorf("unexpected type parameter declaration %s", decl)
+	}
+
+	// constraints from other packages are qualified by package name, as they would be written
+	nodes, err := p.evalTypeSpec(false, typeSpec(f, "Nodes"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decl := nodes.TypeParamsDecl(); decl != "[N ast.Node]" {
+		t.Errorf("unexpected type parameter declaration %s", decl)
+	}
+
+	// instantiation
+	i, _ := p.Eval("int")
+	s, _ := p.Eval("string")
+
+	t3, err3 := t2.Instantiate(s, i)
+
+	if err3 != nil {
+		t.Fatal(err3)
+	}
+
+	if t3.Generic() || t3.String() != "*Pair[string, int]" || !bool(t3.Pointer) {
+		t.Errorf("expected non-generic *Pair[string, int], got %s", t3)
+	}
+
+	// evaluating the instantiation directly should be equivalent
+	t4, err4 := p.Eval("*Pair[string, int]")
+
+	if err4 != nil {
+		t.Fatal(err4)
+	}
+
+	if !types.Identical(t3.Type, t4.Type) {
+		t.Errorf("%s should be identical to %s", t3, t4)
+	}
+
+	// constraints are enforced
+	f64, _ := p.Eval("float64")
+
+	if _, err := t2.Instantiate(s, f64); err == nil {
+		t.Errorf("float64 should not satisfy the constraint of V")
+	}
+
+	if _, err := t2.Instantiate(s); err == nil {
+		t.Errorf("instantiation with too few type arguments should be an error")
+	}
+
+	if _, err := i.Instantiate(s); err == nil {
+		t.Errorf("instantiation of a non-generic type should be an error")
+	}
+}
+
+func TestAlias(t *testing.T) {
+	p, f := testPackage(t, `package alias
+
+import "go/token"
+
+// +test foo
+type Local struct{}
+
+// +test foo
+type Positions = []token.Position
+
+// +test foo
+type Position = token.Position
+
+// +test foo
+type LocalAlias = Local
+
+// +test foo
+type Defined []token.Position
+
+// +test foo
+type Stringer interface{ String() string }
+
+// +test target:"go/token.Pos"
+type _ struct{}
+`)
+
+	a := &ast.Package{
+		Name:  f.Name.Name,
+		Files: map[string]*ast.File{"test.go": f},
+	}
+
+	var errs ErrorList
+	p.annotate(a, "+test", nil, &errs, func(error, token.Pos) bool { return false })
+
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	tests := map[string]struct {
+		alias, methods bool
+	}{
+		"Local":      {false, true},
+		"Positions":  {true, false},
+		"Position":   {true, false},
+		"LocalAlias": {true, true},
+		"Defined":    {false, true},
+		"Stringer":   {false, false},
+		"token.Pos":  {false, false},
+	}
+
+	if len(p.Types) != len(tests) {
+		t.Fatalf("should have found %v types, found %v", len(tests), len(p.Types))
+	}
+
+	for _, typ := range p.Types {
+		test, ok := tests[typ.Name]
+		if !ok {
+			t.Errorf("unexpected type %s", typ)
+			continue
+		}
+
+		if typ.Alias != test.alias {
+			t.Errorf("%s alias should be %v", typ, test.alias)
+		}
+
+		if typ.CanDeclareMethods() != test.methods {
+			t.Errorf("%s CanDeclareMethods should be %v", typ, test.methods)
+		}
+
+		err := typ.CheckMethods()
+
+		if test.methods != (err == nil) {
+			t.Errorf("%s CheckMethods should return an error if and only if methods may not be declared, got %v", typ, err)
+		}
+
+		if e, ok := err.(*Error); err != nil && (!ok || e.Code != CodeInvalidReceiver) {
+			t.Errorf("%s CheckMethods should return an %s *Error, got %v", typ, CodeInvalidReceiver, err)
+		}
+
+		// an alias of a type from another package is foreign, as is the type for which it stands
+		foreign := typ.Name == "Position" || typ.Name == "token.Pos"
+
+		if typ.foreign(p) != foreign {
+			t.Errorf("%s foreign should be %v", typ, foreign)
+		}
+	}
+}
+
+func TestSource(t *testing.T) {
+	p, f := testPackage(t, `package source
+
+// Thing is a thing.
+// +test foo
+//
+// It is documented.
+type Thing struct{}
+
+type (
+	// Other is another thing.
+	//other:foo
+	// +test foo
+	Other int
+)
+
+// Do does things.
+// +test foo
+func Do() {}
+
+const (
+	// Yes is true.
+	// +test foo
+	Yes, No = true, false
+)
+`)
+
+	a := &ast.Package{
+		Name:  f.Name.Name,
+		Files: map[string]*ast.File{"test.go": f},
+	}
+
+	var errs ErrorList
+	p.annotate(a, "+test", nil, &errs, func(error, token.Pos) bool { return false })
+
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	typs := map[string]struct {
+		line int
+		doc  string
+	}{
+		"Thing": {7, "Thing is a thing.\n\nIt is documented.\n"},
+		"Other": {13, "Other is another thing.\n"},
+	}
+
+	if len(p.Types) != len(typs) {
+		t.Fatalf("should have found %v types, found %v", len(typs), len(p.Types))
+	}
+
+	for _, typ := range p.Types {
+		x := typs[typ.Name]
+
+		if typ.Position.Filename != "test.go" || typ.Position.Line != x.line {
+			t.Errorf("%s should be declared at test.go:%v, got %s", typ, x.line, typ.Position)
+		}
+
+		if typ.Spec == nil || typ.Spec.Name.Name != typ.Name {
+			t.Errorf("%s should have its TypeSpec", typ)
+		}
+
+		if typ.File != f {
+			t.Errorf("%s should have its file", typ)
+		}
+
+		if typ.Doc != x.doc {
+			t.Errorf("%s doc should be %q, got %q", typ, x.doc, typ.Doc)
+		}
+
+		// errors point at the declaration
+		_, err := TemplateSlice{}.ByTag(typ, Tag{Name: "foo"})
+
+		if e, ok := err.(*Error); !ok || e.Pos != typ.Position {
+			t.Errorf("template error should be positioned at %s, got %v", typ.Position, err)
+		}
+	}
+
+	targets := map[string]struct {
+		line int
+		doc  string
+	}{
+		"Do":  {18, "Do does things.\n"},
+		"Yes": {23, "Yes is true.\n"},
+	}
+
+	if len(p.Targets) != len(targets) {
+		t.Fatalf("should have found %v targets, found %v", len(targets), len(p.Targets))
+	}
+
+	for _, target := range p.Targets {
+		x := targets[target.Name]
+
+		if target.Position.Line != x.line || target.File != f || target.Node == nil {
+			t.Errorf("%s should be declared at test.go:%v, got %s", target, x.line, target.Position)
+		}
+
+		if target.Doc != x.doc {
+			t.Errorf("%s doc should be %q, got %q", target, x.doc, target.Doc)
+		}
+	}
+
+	if _, ok := p.Targets[1].Node.(*ast.ValueSpec); !ok {
+		t.Errorf("Yes should be annotated on its ValueSpec, got %T", p.Targets[1].Node)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"go/parser"
 	"go/token"
 	"os"
+	"sort"
 	"strings"
 )
 
@@ -14,26 +15,51 @@ var ignored = func(f os.FileInfo) bool {
 	return !strings.HasPrefix(f.Name(), "_") && !strings.HasPrefix(f.Name(), ".")
 }
 
-func getPackages(directive string, conf *Config) ([]*Package, error) {
-	// wrap filter with default filter
-	filt := func(f os.FileInfo) bool {
-		if conf.Filter != nil {
-			return ignored(f) && conf.Filter(f)
+func getPackages(directives []string, conf *Config) ([]*Package, error) {
+	for _, directive := range directives {
+		if err := validateDirective(directive); err != nil {
+			return nil, err
 		}
-		return ignored(f)
 	}
 
 	// get the AST
 	fset := token.NewFileSet()
-	astPkgs, err := parser.ParseDir(fset, "./", filt, parser.ParseComments)
+	astPkgs, err := parser.ParseDir(fset, "./", conf.filter(), parser.ParseComments)
 
 	if err != nil {
 		return nil, err
 	}
 
+	// annotation errors are collected across all files, rather than returning the first
+	var errs ErrorList
+
+	// annotations from the optional annotation file, keyed by directive and type name
+	filename := conf.AnnotationFile
+	if filename == "" {
+		filename = AnnotationFile
+	}
+
+	fileAnnotations, err := readAnnotationFile(fset, filename, directives)
+	errs.add(err)
+
 	var pkgs []*Package
 	var typeCheckErrors []*TypeCheckError
 
+	// evalError records an error evaluating an annotated type or type parameter, and reports
+	// whether it was ignored; type check errors may be ignored, see Config
+	evalError := func(err error, pos token.Pos) bool {
+		if tc, ok := err.(*TypeCheckError); ok && conf.IgnoreTypeCheckErrors {
+			tc.ignored = true
+			tc.addPos(fset, pos)
+			typeCheckErrors = append(typeCheckErrors, tc)
+			return true
+		}
+
+		// some errors come with empty pos
+		errs.Add(fset.Position(pos), CodeTypeCheck, strings.TrimLeft(err.Error(), ":- "))
+		return false
+	}
+
 	for _, a := range astPkgs {
 		pkg, err := getPackage(fset, a, conf)
 
@@ -48,84 +74,169 @@ func getPackages(directive string, conf *Config) ([]*Package, error) {
 		}
 
 		pkgs = append(pkgs, pkg)
+		pkg.directives = directives
 
-		specs := getTaggedComments(a, directive)
+		for _, directive := range directives {
+			pkg.annotate(a, directive, fileAnnotations[directive], &errs, evalError)
+		}
+	}
 
-		for s, c := range specs {
-			pointer, tags, err := parse(fset, c, directive)
+	// annotations in the file must refer to declared types
+	for _, annotations := range fileAnnotations {
+		for name, fa := range annotations {
+			errs.Add(fset.Position(fa.pos), CodeUndeclared, fmt.Sprintf("type %s is not declared", name))
+		}
+	}
 
-			if err != nil {
-				return nil, err
-			}
+	// if we have type check errors, but are ignoring them, output as FYI
+	if err := combine(typeCheckErrors); err != nil && conf.IgnoreTypeCheckErrors {
+		fmt.Println(err)
+	}
 
-			// evaluate the annotated type
-			typ, evalErr := pkg.Eval(pointer.String() + s.Name.Name)
+	if len(errs) > 0 {
+		errs.Sort()
+		return pkgs, errs
+	}
 
-			if evalErr != nil {
-				// if we're not ignoring, can return immediately, normal behavior
-				if !conf.IgnoreTypeCheckErrors {
-					return pkgs, evalErr
-				}
+	return pkgs, nil
+}
 
-				// is it a TypeCheckError?
-				tc, isTypeCheckError := evalErr.(*TypeCheckError)
+// docDirectives returns the directives to leave out of doc text: the one being annotated, and any others of the App
+func (pkg *Package) docDirectives(directive string) []string {
+	return append([]string{directive}, pkg.directives...)
+}
 
-				// if not a TypeCheckError, can return immediately, normal behavior
-				if !isTypeCheckError {
-					return pkgs, evalErr
-				}
+// annotate adds the declarations of the package which are annotated with the directive to its Types and Targets.
+// Annotations from the file are removed from fileAnnotations as they are found.
+func (pkg *Package) annotate(a *ast.Package, directive string, fileAnnotations map[string]*annotation, errs *ErrorList, evalError func(error, token.Pos) bool) {
+	fset := pkg.fset
 
-				tc.ignored = conf.IgnoreTypeCheckErrors
-				tc.addPos(fset, s.Pos())
+	annotations := make(map[*ast.TypeSpec]*annotation)
 
-				typeCheckErrors = append(typeCheckErrors, tc)
-			}
+	for s, c := range getTaggedComments(a, directive) {
+		an, err := newCommentAnnotation(fset, c, directive)
+
+		if err != nil {
+			errs.add(err)
+			continue
+		}
+
+		annotations[s] = an
+	}
+
+	// merge annotations from the file, for types declared in this package
+	typeSpecs := getTypeSpecs(a)
+
+	for name, fa := range fileAnnotations {
+		s, found := typeSpecs[name]
+		if !found {
+			continue
+		}
+
+		// only once, there may be more than one package
+		delete(fileAnnotations, name)
+
+		if an, found := annotations[s]; found {
+			errs.add(an.merge(fset, name, fa))
+			continue
+		}
+
+		annotations[s] = fa
+	}
 
-			// evaluate type parameters
-			for _, tag := range tags {
-				for i, val := range tag.Values {
-					for _, item := range val.typeParameters {
-						tp, evalErr := pkg.Eval(item.val)
+	for s, an := range annotations {
+		pointer, tags := an.pointer, an.tags
 
-						if evalErr != nil {
-							// if we're not ignoring, can return immediately, normal behavior
-							if !conf.IgnoreTypeCheckErrors {
-								return pkgs, evalErr
-							}
+		// evaluate the annotated type, or the type from another package it stands in for
+		var typ Type
+		var evalErr error
 
-							// is it a TypeCheckError?
-							tc, isTypeCheckError := evalErr.(*TypeCheckError)
+		if target, found := findTag(tags, targetTag); found {
+			tags = withoutTag(tags, targetTag)
+			typ, evalErr = pkg.evalTarget(pointer, target)
 
-							// if not a TypeCheckError, can return immediately, normal behavior
-							if !isTypeCheckError {
-								return pkgs, evalErr
-							}
+			if evalErr != nil {
+				errs.Add(fset.Position(an.tagPos[targetTag]), CodeInvalidTarget, evalErr.Error())
+				continue
+			}
+		} else {
+			typ, evalErr = pkg.evalTypeSpec(pointer, s)
 
-							tc.ignored = conf.IgnoreTypeCheckErrors
-							tc.addPos(fset, item.pos+c.Slash)
+			if evalErr != nil && !evalError(evalErr, s.Pos()) {
+				continue
+			}
+		}
 
-							typeCheckErrors = append(typeCheckErrors, tc)
-						}
+		// evaluate type parameters
+		for _, tag := range tags {
+			for i, val := range tag.Values {
+				for _, item := range val.typeParameters {
+					tp, evalErr := pkg.evalIn(s.Pos(), item.val)
 
-						val.TypeParameters = append(val.TypeParameters, tp)
+					if evalErr != nil {
+						evalError(evalErr, item.pos)
 					}
-					tag.Values[i] = val // mutate the original
+
+					val.TypeParameters = append(val.TypeParameters, tp)
 				}
-				typ.Tags = append(typ.Tags, tag)
+				tag.Values[i] = val // mutate the original
 			}
+			typ.Tags = append(typ.Tags, tag)
+		}
 
-			typ.test = test(strings.HasSuffix(fset.Position(s.Pos()).Filename, "_test.go"))
-
-			pkg.Types = append(pkg.Types, typ)
+		if st, ok := s.Type.(*ast.StructType); ok && !typ.foreign(pkg) {
+			fields, err := getFields(fset, st, directive)
+			errs.add(err)
+			typ.fields = fields
 		}
-	}
 
-	// if we have type check errors, but are ignoring them, output as FYI
-	if err := combine(typeCheckErrors); err != nil && conf.IgnoreTypeCheckErrors {
-		fmt.Println(err)
+		typ.Directive = directive
+		typ.Alias = s.Assign.IsValid()
+		typ.receiver = receiverError(typ, pkg.Package)
+		typ.Position = fset.Position(s.Pos())
+		typ.Spec = s
+		typ.File = a.Files[typ.Position.Filename]
+		typ.Doc = docText(s.Doc, pkg.docDirectives(directive)...)
+		typ.test = test(strings.HasSuffix(typ.Position.Filename, "_test.go"))
+
+		pkg.Types = append(pkg.Types, typ)
 	}
 
-	return pkgs, nil
+	for _, s := range getTaggedTargets(a, directive) {
+		pointer, tags, err := parse(fset, s.comment, directive)
+
+		if err != nil {
+			errs.add(err)
+			continue
+		}
+
+		if pointer {
+			errs.Add(fset.Position(s.comment.Slash), CodeInvalidPointer, fmt.Sprintf("pointer declaration is not valid on a %s", s.kind))
+			continue
+		}
+
+		pos := fset.Position(s.idents[0].Pos())
+
+		target := Target{
+			Kind:      s.kind,
+			Name:      s.name,
+			Tags:      tags,
+			Directive: directive,
+			Position:  pos,
+			Node:      s.node,
+			File:      a.Files[pos.Filename],
+			Doc:       docText(s.doc, pkg.docDirectives(directive)...),
+			test:      test(strings.HasSuffix(pos.Filename, "_test.go")),
+		}
+
+		for _, ident := range s.idents {
+			if obj := pkg.info.Defs[ident]; obj != nil {
+				target.Objects = append(target.Objects, obj)
+			}
+		}
+
+		pkg.Targets = append(pkg.Targets, target)
+	}
 }
 
 // getTaggedComments walks the AST and returns types which have directive comment
@@ -163,6 +274,231 @@ func getTaggedComments(pkg *ast.Package, directive string) map[*ast.TypeSpec]*as
 	return specs
 }
 
+// withoutTag returns the tags, less the named tag
+func withoutTag(tags TagSlice, name string) TagSlice {
+	var result TagSlice
+	for _, t := range tags {
+		if t.Name != name {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// getFields returns the fields of a struct, with tags parsed from the directive in each field's doc or line comment
+func getFields(fset *token.FileSet, st *ast.StructType, directive string) ([]Field, error) {
+	var fields []Field
+	var errs ErrorList
+
+	for _, f := range st.Fields.List {
+		var tags TagSlice
+
+		for _, doc := range []*ast.CommentGroup{f.Doc, f.Comment} {
+			c := findAnnotation(doc, directive)
+			if c == nil {
+				continue
+			}
+
+			pointer, ts, err := parse(fset, c, directive)
+
+			if err != nil {
+				errs.add(err)
+				continue
+			}
+
+			if pointer {
+				errs.Add(fset.Position(c.Slash), CodeInvalidPointer, "pointer declaration is not valid on a field")
+				continue
+			}
+
+			for _, t := range ts {
+				if _, found := findTag(tags, t.Name); found {
+					errs.Add(fset.Position(c.Slash), CodeDuplicateTag, fmt.Sprintf("duplicate tag %q", t.Name))
+					continue
+				}
+				tags = append(tags, t)
+			}
+		}
+
+		if len(f.Names) == 0 {
+			// embedded field is named for its type
+			fields = append(fields, Field{Name: embeddedName(f.Type), Tags: tags})
+			continue
+		}
+
+		for _, name := range f.Names {
+			fields = append(fields, Field{Name: name.Name, Tags: tags})
+		}
+	}
+
+	return fields, errs.Err()
+}
+
+// embeddedName returns the field name of an embedded type, eg *pkg.Foo is named Foo
+func embeddedName(expr ast.Expr) string {
+	switch x := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(x.X)
+	case *ast.SelectorExpr:
+		return x.Sel.Name
+	case *ast.Ident:
+		return x.Name
+	}
+	return ""
+}
+
+// taggedTarget is an annotated func, const or var declaration, prior to evaluation
+type taggedTarget struct {
+	kind    TargetKind
+	name    string
+	idents  []*ast.Ident
+	comment *ast.Comment
+	node    ast.Node
+	doc     *ast.CommentGroup
+}
+
+// getTaggedTargets walks the top-level declarations of the package and returns funcs,
+// and const and var declarations, which have a directive comment
+func getTaggedTargets(pkg *ast.Package, directive string) []taggedTarget {
+	var targets []taggedTarget
+
+	// sort files for a predictable order of targets
+	var names []string
+	for name := range pkg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, d := range pkg.Files[name].Decls {
+			switch d := d.(type) {
+			case *ast.FuncDecl:
+				c := findAnnotation(d.Doc, directive)
+				if c == nil {
+					continue
+				}
+
+				name := d.Name.Name
+				if recv := receiverName(d); recv != "" {
+					name = recv + "." + name
+				}
+
+				targets = append(targets, taggedTarget{FuncTarget, name, []*ast.Ident{d.Name}, c, d, d.Doc})
+			case *ast.GenDecl:
+				var kind TargetKind
+				switch d.Tok {
+				case token.CONST:
+					kind = ConstTarget
+				case token.VAR:
+					kind = VarTarget
+				default:
+					continue
+				}
+
+				// a directive on the declaration applies to all of its specs
+				if c := findAnnotation(d.Doc, directive); c != nil {
+					var idents []*ast.Ident
+					for _, s := range d.Specs {
+						idents = append(idents, s.(*ast.ValueSpec).Names...)
+					}
+					if len(idents) == 0 {
+						// an empty declaration, eg const (), declares nothing to annotate
+						continue
+					}
+					targets = append(targets, taggedTarget{kind, idents[0].Name, idents, c, d, d.Doc})
+					continue
+				}
+
+				if d.Lparen == 0 {
+					// not parenthesized, and the declaration has no directive
+					continue
+				}
+
+				for _, s := range d.Specs {
+					v := s.(*ast.ValueSpec)
+					if c := findAnnotation(v.Doc, directive); c != nil {
+						targets = append(targets, taggedTarget{kind, v.Names[0].Name, v.Names, c, v, v.Doc})
+					}
+				}
+			}
+		}
+	}
+
+	return targets
+}
+
+// receiverName returns the name of the receiver's type, if the func is a method
+func receiverName(f *ast.FuncDecl) string {
+	if f.Recv == nil || len(f.Recv.List) == 0 {
+		return ""
+	}
+
+	expr := f.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	// generic receiver, eg Tree[T]
+	switch x := expr.(type) {
+	case *ast.IndexExpr:
+		expr = x.X
+	case *ast.IndexListExpr:
+		expr = x.X
+	}
+
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+
+	return ""
+}
+
+// getTypeSpecs returns the top-level type declarations of the package, keyed by name
+func getTypeSpecs(pkg *ast.Package) map[string]*ast.TypeSpec {
+	specs := make(map[string]*ast.TypeSpec)
+
+	for _, f := range pkg.Files {
+		for _, d := range f.Decls {
+			g, ok := d.(*ast.GenDecl)
+			if !ok || g.Tok != token.TYPE {
+				continue
+			}
+
+			for _, s := range g.Specs {
+				t := s.(*ast.TypeSpec)
+				specs[t.Name.Name] = t
+			}
+		}
+	}
+
+	return specs
+}
+
+// docText returns the text of a doc comment, less lines which are annotated by any of the directives, as found by
+// findAnnotation, and +build constraints
+func docText(doc *ast.CommentGroup, directives ...string) string {
+	if doc == nil {
+		return ""
+	}
+
+	g := &ast.CommentGroup{}
+
+lines:
+	for _, c := range doc.List {
+		for _, directive := range directives {
+			if isAnnotation(c.Text, directive) {
+				continue lines
+			}
+		}
+		if isAnnotation(c.Text, "+build") {
+			continue
+		}
+		g.List = append(g.List, c)
+	}
+
+	return g.Text()
+}
+
 // findDirective return the first line of a doc which contains a directive
 // the directive and '//' are removed
 func findAnnotation(doc *ast.CommentGroup, directive string) *ast.Comment {
@@ -172,25 +508,32 @@ func findAnnotation(doc *ast.CommentGroup, directive string) *ast.Comment {
 
 	// check lines of doc for directive
 	for _, c := range doc.List {
-		l := c.Text
-		// does the line start with the directive?
-		t := strings.TrimLeft(l, "/ ")
-		if !strings.HasPrefix(t, directive) {
-			continue
+		if isAnnotation(c.Text, directive) {
+			return c
 		}
+	}
 
-		// remove the directive from the line
-		t = strings.TrimPrefix(t, directive)
+	return nil
+}
 
-		// must be eof or followed by a space
-		if len(t) > 0 && t[0] != ' ' {
-			continue
-		}
+// isAnnotation reports whether a comment line is annotated by the directive
+func isAnnotation(l, directive string) bool {
+	if goStyle(directive) {
+		// must immediately follow the slashes, as with Go directives
+		return strings.HasPrefix(l, "//"+directive)
+	}
 
-		return c
+	// does the line start with the directive?
+	t := strings.TrimLeft(l, "/ ")
+	if !strings.HasPrefix(t, directive) {
+		return false
 	}
 
-	return nil
+	// remove the directive from the line
+	t = strings.TrimPrefix(t, directive)
+
+	// must be eof or followed by a space
+	return len(t) == 0 || t[0] == ' '
 }
 
 type parsr struct {
@@ -199,6 +542,7 @@ type parsr struct {
 	peekCount int
 	fset      *token.FileSet
 	offset    token.Pos
+	tag       string // the current tag, for diagnostics
 }
 
 // next returns the next token.
@@ -226,23 +570,38 @@ func (p *parsr) peek() item {
 	return p.token[0]
 }
 
-func (p *parsr) errorf(item item, format string, args ...interface{}) error {
+func (p *parsr) errorf(item item, code Code, format string, args ...interface{}) *Error {
 	// some errors come with empty pos
-	format = strings.TrimLeft(format, ":- ")
-	// prepend position information (file name, line, column)
-	format = fmt.Sprintf("%s: %s", p.fset.Position(item.pos+p.offset), format)
-	return fmt.Errorf(format, args...)
+	msg := strings.TrimLeft(fmt.Sprintf(format, args...), ":- ")
+
+	pos := item.pos + p.offset
+	end := pos
+	if item.typ != itemError {
+		// the value of an error item is its message
+		end += token.Pos(len(item.val))
+	}
+
+	return &Error{Diagnostic{
+		Pos:  p.fset.Position(pos),
+		End:  p.fset.Position(end),
+		Code: code,
+		Tag:  p.tag,
+		Msg:  msg,
+	}}
 }
 
-func (p *parsr) unexpected(item item) error {
-	return p.errorf(item, "unexpected '%v'", item.val)
+func (p *parsr) unexpected(item item) *Error {
+	return p.errorf(item, CodeSyntax, "unexpected '%v'", item.val)
 }
 
+// parse parses a directive comment. Rather than stopping at the first error, parsing continues
+// with the next tag, such that all errors in the comment are returned as an ErrorList.
 func parse(fset *token.FileSet, comment *ast.Comment, directive string) (Pointer, TagSlice, error) {
 	var pointer Pointer
 	var tags TagSlice
+	var errs ErrorList
 	p := &parsr{
-		lex:    lex(comment.Text),
+		lex:    lex(comment.Text, directive),
 		fset:   fset,
 		offset: comment.Slash,
 	}
@@ -252,13 +611,14 @@ func parse(fset *token.FileSet, comment *ast.Comment, directive string) (Pointer
 
 Loop:
 	for {
+		p.tag = ""
 		item := p.next()
 		switch item.typ {
 		case itemEOF:
 			break Loop
 		case itemError:
-			err := p.errorf(item, item.val)
-			return false, nil, err
+			// the lexer has skipped to the next tag
+			errs.add(p.errorf(item, CodeSyntax, "%s", item.val))
 		case itemCommentPrefix:
 			// don't care, move on
 			continue
@@ -271,14 +631,14 @@ Loop:
 		case itemPointer:
 			// have we already seen a pointer?
 			if pointer {
-				err := p.errorf(item, "second pointer declaration")
-				return false, nil, err
+				errs.add(p.errorf(item, CodeInvalidPointer, "second pointer declaration"))
+				continue
 			}
 
 			// have we already seen tags? pointer must be first
-			if len(tags) > 0 {
-				err := p.errorf(item, "pointer declaration must precede tags")
-				return false, nil, err
+			if len(exists) > 0 {
+				errs.add(p.errorf(item, CodeInvalidPointer, "pointer declaration must precede tags"))
+				continue
 			}
 
 			pointer = true
@@ -287,11 +647,12 @@ Loop:
 			tag := Tag{
 				Name: item.val,
 			}
+			p.tag = tag.Name
 
 			// check for duplicate
-			if _, seen := exists[tag.Name]; seen {
-				err := p.errorf(item, "duplicate tag %q", tag.Name)
-				return pointer, nil, err
+			_, duplicate := exists[tag.Name]
+			if duplicate {
+				errs.add(p.errorf(item, CodeDuplicateTag, "duplicate tag %q", tag.Name))
 			}
 
 			// mark tag as previously seen
@@ -303,64 +664,129 @@ Loop:
 				negated, vals, err := parseTagValues(p)
 
 				if err != nil {
-					return false, nil, err
+					errs.add(err)
+					continue
 				}
 
 				tag.Negated = negated
 				tag.Values = vals
 			}
 
-			tags = append(tags, tag)
+			if !duplicate {
+				tags = append(tags, tag)
+			}
 		default:
-			return false, nil, p.unexpected(item)
+			errs.add(p.unexpected(item))
 		}
 	}
 
+	if len(errs) > 0 {
+		return false, nil, errs
+	}
+
 	return pointer, tags, nil
 }
 
+// parseTagValues parses the quoted values of a tag, continuing to the close quote in the presence of errors.
+// A single leading minus negates the list, eg "-Where,Count"; otherwise, minus and plus apply to the value they precede,
+// and a list with a leading minus must prefix every value, eg "-Where,+Count,-Any".
 func parseTagValues(p *parsr) (bool, []TagValue, error) {
-	var negated bool
 	var vals []TagValue
+	var items []item // of the values, for error reporting
+	var errs ErrorList
+
+	// the minus or plus preceding the next value
+	var prefix *item
 
 	for {
 		item := p.next()
 
 		switch item.typ {
 		case itemError:
-			err := p.errorf(item, item.val)
-			return false, nil, err
+			// the lexer has skipped the remainder of the tag
+			errs.add(p.errorf(item, CodeSyntax, "%s", item.val))
+			return false, nil, errs
 		case itemEOF:
-			// shouldn't happen within a tag
-			err := p.errorf(item, "expected a close quote")
-			return false, nil, err
-		case itemMinus:
-			if len(vals) > 0 {
-				err := p.errorf(item, "negation must precede tag values")
-				return false, nil, err
+			// shouldn't happen within a tag; leave it to end the comment
+			p.backup()
+			errs.add(p.errorf(item, CodeSyntax, "expected a close quote"))
+			return false, nil, errs
+		case itemMinus, itemPlus:
+			if prefix != nil {
+				errs.add(p.errorf(item, CodeSyntax, "expected a tag value following '%s'", prefix.val))
+				continue
 			}
-			negated = true
+			prefix = &item
 		case itemTagValue:
 			val := TagValue{
 				Name: item.val,
 			}
 
+			if prefix != nil {
+				val.Negated = prefix.typ == itemMinus
+				val.Added = prefix.typ == itemPlus
+				prefix = nil
+			}
+
 			if p.peek().typ == itemTypeParameter {
 				tokens, err := parseTypeParameters(p)
 				if err != nil {
-					return false, nil, err
+					errs.add(err)
+					continue
 				}
 				val.typeParameters = tokens
 			}
 
 			vals = append(vals, val)
+			items = append(items, item)
 		case itemCloseQuote:
+			// a lone minus negates an empty list
+			negated := prefix != nil && prefix.typ == itemMinus && len(vals) == 0
+
+			if prefix != nil && !negated {
+				errs.add(p.errorf(*prefix, CodeSyntax, "expected a tag value following '%s'", prefix.val))
+			}
+
 			// we're done
+			if len(errs) > 0 {
+				return false, nil, errs
+			}
+
+			if len(vals) > 0 && vals[0].Negated {
+				if !individuallyPrefixed(vals[1:]) {
+					// a leading minus, with no other prefixes, negates the list
+					vals[0].Negated = false
+					negated = true
+					return negated, vals, nil
+				}
+
+				// otherwise, an unprefixed value might be meant to be excluded, as it would be without the other prefixes
+				for i, v := range vals {
+					if !v.Negated && !v.Added {
+						errs.add(p.errorf(items[i], CodeSyntax, "value %s must be prefixed with '+' or '-' in a list with a leading '-'", v.Name))
+					}
+				}
+
+				if len(errs) > 0 {
+					return false, nil, errs
+				}
+			}
+
 			return negated, vals, nil
 		default:
-			return false, nil, p.unexpected(item)
+			errs.add(p.unexpected(item))
+		}
+	}
+}
+
+// individuallyPrefixed reports whether any of the values is prefixed with a minus or plus
+func individuallyPrefixed(vals []TagValue) bool {
+	for _, v := range vals {
+		if v.Negated || v.Added {
+			return true
 		}
 	}
+	return false
 }
 
 func parseTypeParameters(p *parsr) ([]item, error) {
@@ -371,6 +797,8 @@ func parseTypeParameters(p *parsr) ([]item, error) {
 
 		switch item.typ {
 		case itemTypeParameter:
+			// positions are relative to the comment; make them absolute
+			item.pos += p.offset
 			result = append(result, item)
 		default:
 			p.backup()
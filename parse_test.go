@@ -3,8 +3,13 @@ package typewriter
 import (
 	"fmt"
 	"go/ast"
+	"go/parser"
 	"go/token"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"testing"
 )
@@ -45,6 +50,91 @@ func TestFindDirective(t *testing.T) {
 			t.Errorf("[test %v] found should have been %v for:\n%s", i, test.found, test.text)
 		}
 	}
+
+	// directives in the style of //go:generate
+	goTests := []findDirectiveTest{
+		{`//test:`, true},
+		{`//test:foo:"bar,Baz"`, true},
+		{`//test:* foo`, true},
+		{`// test:foo`, false},
+		{`//tested:foo`, false},
+		{`// +test foo`, false},
+	}
+
+	for i, test := range goTests {
+		g := &ast.CommentGroup{
+			List: []*ast.Comment{{Text: test.text}},
+		}
+		c := findAnnotation(g, "test:")
+		found := c != nil
+		if found != test.found {
+			t.Errorf("[go test %v] found should have been %v for:\n%s", i, test.found, test.text)
+		}
+	}
+}
+
+func TestDocText(t *testing.T) {
+	tests := []struct {
+		directive string
+		text      string
+	}{
+		{"+test", `// +test`},
+		{"+test", `// +test foo:"bar,Baz"`},
+		{"+test", `//+test foo:"bar,Baz"`},
+		{"+test", `// +test * foo:"bar,Baz"`},
+		{"+test", `// +build linux`},
+		{"test:", `//test:`},
+		{"test:", `//test:foo:"bar,Baz"`},
+		{"test:", `//test:* foo`},
+		{"Test:", `//Test:foo`},
+		{"Test:", `//Test:* foo`},
+	}
+
+	for i, test := range tests {
+		g := &ast.CommentGroup{
+			List: []*ast.Comment{{Text: "// Thing is a thing."}, {Text: test.text}},
+		}
+		if doc := docText(g, test.directive); doc != "Thing is a thing.\n" {
+			t.Errorf("[test %v] %s should be left out of the doc, got %q", i, test.text, doc)
+		}
+	}
+
+	// the annotations of every directive are left out
+	g := &ast.CommentGroup{
+		List: []*ast.Comment{{Text: "// +test foo"}, {Text: "// Thing is a thing."}, {Text: "//Other:* bar"}},
+	}
+	if doc := docText(g, "+test", "Other:"); doc != "Thing is a thing.\n" {
+		t.Errorf("annotations of both directives should be left out of the doc, got %q", doc)
+	}
+
+	// lines which merely resemble a directive are kept
+	g = &ast.CommentGroup{
+		List: []*ast.Comment{{Text: "// Thing is a thing."}, {Text: "// test: not a directive"}, {Text: "// +Inf is returned when empty,"}, {Text: "// +1 for each thing"}},
+	}
+	if doc := docText(g, "test:"); doc != "Thing is a thing.\ntest: not a directive\n+Inf is returned when empty,\n+1 for each thing\n" {
+		t.Errorf("a comment which is not an annotation should be kept, got %q", doc)
+	}
+}
+
+func TestValidateDirective(t *testing.T) {
+	valid := []string{"+gen", "+test", "gen:", "tw:", "+gen2"}
+	invalid := []string{"", "+", ":", "gen", "+gen:", "//gen:", "+ge-n", "ge n:"}
+
+	for _, d := range valid {
+		if err := validateDirective(d); err != nil {
+			t.Errorf("%q should be valid, got %v", d, err)
+		}
+	}
+
+	for _, d := range invalid {
+		if err := validateDirective(d); err == nil {
+			t.Errorf("%q should be invalid", d)
+		}
+	}
+
+	if _, err := getPackages([]string{"gen"}, DefaultConfig); err == nil {
+		t.Errorf("getPackages should return an error for an invalid directive")
+	}
 }
 
 type parseTest struct {
@@ -54,115 +144,387 @@ type parseTest struct {
 	valid   bool
 }
 
+// parseTests are shared with the fuzz targets, as a seed corpus
+var parseTests = []parseTest{
+	{`// +test foo`, false, TagSlice{
+		{"foo", []TagValue{}, false},
+	}, true},
+	{`// +test foo bar`, false, TagSlice{
+		{"foo", []TagValue{}, false},
+		{"bar", []TagValue{}, false},
+	}, true},
+	{`// +test foo:"bar,Baz"`, false, TagSlice{
+		{"foo", []TagValue{
+			{Name: "bar"},
+			{Name: "Baz"},
+		}, false},
+	}, true},
+	{`// +test * foo:"bar,Baz"`, true, TagSlice{
+		{"foo", []TagValue{
+			{Name: "bar"},
+			{Name: "Baz"},
+		}, false},
+	}, true},
+	{`// +test foo:"bar,Baz" qux:"stuff"`, false, TagSlice{
+		{"foo", []TagValue{
+			{Name: "bar"},
+			{Name: "Baz"},
+		}, false},
+		{"qux", []TagValue{
+			{Name: "stuff"},
+		}, false},
+	}, true},
+	{`// +test foo:"-bar,Baz"`, false, TagSlice{
+		{"foo", []TagValue{
+			{Name: "bar"},
+			{Name: "Baz"},
+		}, true},
+	}, true},
+	{`// +test foo:"bar,-Baz"`, false, TagSlice{
+		{"foo", []TagValue{
+			{Name: "bar"},
+			{Name: "Baz", Negated: true},
+		}, false},
+	}, true},
+	{`// +test foo:"-bar,-Baz,+qux[int]"`, false, TagSlice{
+		{"foo", []TagValue{
+			{Name: "bar", Negated: true},
+			{Name: "Baz", Negated: true},
+			{Name: "qux", Added: true, typeParameters: []item{{val: "int"}}},
+		}, false},
+	}, true},
+	{`// +test foo:"-"`, false, TagSlice{
+		{"foo", []TagValue{}, true},
+	}, true},
+	{`// +test foo:"bar  ,Baz "  `, false, TagSlice{
+		{"foo", []TagValue{
+			{Name: "bar"},
+			{Name: "Baz"},
+		}, false},
+	}, true},
+	{`// +test foo:"bar,Baz[qaz], qux"`, false, TagSlice{
+		{"foo", []TagValue{
+			{Name: "bar"},
+			{Name: "Baz", typeParameters: []item{{val: "qaz"}}},
+			{Name: "qux"},
+		}, false},
+	}, true},
+	{`// +test foo:"bar,Baz[[]qaz]"`, false, TagSlice{
+		{"foo", []TagValue{
+			{Name: "bar"},
+			{Name: "Baz", typeParameters: []item{{val: "[]qaz"}}},
+		}, false},
+	}, true},
+	{`// +test foo:"bar,Baz[qaz,hey]" qux:"stuff"`, false, TagSlice{
+		{"foo", []TagValue{
+			{Name: "bar"},
+			{Name: "Baz", typeParameters: []item{{val: "qaz"}, {val: "hey"}}},
+		}, false},
+		{"qux", []TagValue{
+			{Name: "stuff"},
+		}, false},
+	}, true},
+	{`// +test foo:"Baz[qaz],yo[dude]" qux:"stuff[things]"`, false, TagSlice{
+		{"foo", []TagValue{
+			{Name: "Baz", typeParameters: []item{{val: "qaz"}}},
+			{Name: "yo", typeParameters: []item{{val: "dude"}}},
+		}, false},
+		{"qux", []TagValue{
+			{Name: "stuff", typeParameters: []item{{val: "things"}}},
+		}, false},
+	}, true},
+	{`// +test target:"github.com/x/pb-go.User" foo:"bar"`, false, TagSlice{
+		{"target", []TagValue{
+			{Name: "github.com/x/pb-go.User"},
+		}, false},
+		{"foo", []TagValue{
+			{Name: "bar"},
+		}, false},
+	}, true},
+	{`// +test foo:"GroupBy[time.Time],Execute[github.com/x/pb-go.User,map[string]*tm.Duration]"`, false, TagSlice{
+		{"foo", []TagValue{
+			{Name: "GroupBy", typeParameters: []item{{val: "time.Time"}}},
+			{Name: "Execute", typeParameters: []item{{val: "github.com/x/pb-go.User"}, {val: "map[string]*tm.Duration"}}},
+		}, false},
+	}, true},
+	{`// +test foo:"bar,Baz`, false, nil, false},
+	{`// +test foo:"pb.Us|er"`, false, nil, false},
+	{`// +test foo:"bar,--Baz"`, false, nil, false},
+	{`// +test foo:"bar,+"`, false, nil, false},
+	{`// +test foo:"bar,Baz-"`, false, nil, false},
+	{`// +test foo:bar,Baz" qux:"stuff"`, false, nil, false},
+	{`// +test foo"bar,Baz" qux:"stuff"`, false, nil, false},
+	{`// +test foo:"bar,Baz" 8qux:"stuff"`, false, nil, false},
+	{`// +test fo^o:"bar,Baz" qux:"stuff"`, false, nil, false},
+	{`// +test foo:"bar,Ba|z" qux:"stuff"`, false, nil, false},
+	{`// +test foo:"bar,Baz" qux:"stuff`, false, nil, false},
+	{`// +test *foo:"bar,Baz" qux:"stuff"`, false, nil, false},
+	{`// +test foo:"bar,Baz" * qux:"stuff"`, false, nil, false},
+	{`// +test * foo:"bar,Baz" * qux:"stuff"`, false, nil, false},
+	{`// +test foo:"bar,Baz[foo"`, false, nil, false},
+	{`// +test foo:"bar,Baz[foo]]"`, false, nil, false},
+	{`// +test foo:"bar,Baz[[]foo"`, false, nil, false},
+	{`// +test foof:"bar,Baz" foof:"qux"`, false, nil, false},
+}
+
 func TestParse(t *testing.T) {
+	fset := token.NewFileSet()
+
+	for i, test := range parseTests {
+		c := &ast.Comment{
+			Text: test.comment,
+		}
+		pointer, tags, err := parse(fset, c, "+test")
+
+		if test.valid != (err == nil) {
+			t.Errorf("[test %v] valid should have been %v for: %s\n%s", i, test.valid, test.comment, err)
+		}
+
+		if pointer != test.pointer {
+			t.Errorf("[test %v] pointer should have been %v for: \n%s", i, bool(test.pointer), test.comment)
+		}
+
+		if !tagsEqual(tags, test.tags) || !typeParametersEqual(tags, test.tags) {
+			t.Fatalf("[test %v] tags should have been \n%v, got \n%v", i, test.tags, tags)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, test := range parseTests {
+		f.Add(test.comment)
+	}
+
+	f.Fuzz(func(t *testing.T, comment string) {
+		fset := token.NewFileSet()
+		file := fset.AddFile("fuzz.go", -1, len(comment)+1)
+		c := &ast.Comment{
+			Slash: file.Pos(0),
+			Text:  comment,
+		}
+
+		before := runtime.NumGoroutine()
+
+		pointer, tags, err := parse(fset, c, "+test")
+
+		if after := runtime.NumGoroutine(); after > before {
+			t.Fatalf("parse should not start goroutines; had %v, now %v", before, after)
+		}
+
+		if err != nil {
+			errs, ok := err.(ErrorList)
+			if !ok || len(errs) == 0 {
+				t.Fatalf("errors should be returned as a non-empty ErrorList, got %#v", err)
+			}
+
+			for _, e := range errs {
+				if !e.Pos.IsValid() || e.Pos.Offset > len(comment) || e.End.Offset < e.Pos.Offset || e.End.Offset > len(comment) {
+					t.Fatalf("error %q spans %v to %v, outside of %q", e.Msg, e.Pos.Offset, e.End.Offset, comment)
+				}
+			}
+
+			if pointer || tags != nil {
+				t.Fatalf("an error should return no pointer or tags for %q", comment)
+			}
+
+			return
+		}
+
+		exists := make(map[string]bool)
+		for _, tag := range tags {
+			if tag.Name == "" || exists[tag.Name] {
+				t.Fatalf("tag names should be non-empty and unique, got %v for %q", tags, comment)
+			}
+			exists[tag.Name] = true
+
+			for _, v := range tag.Values {
+				if v.Name == "" {
+					t.Fatalf("tag values should be non-empty, got %v for %q", tag.Values, comment)
+				}
+			}
+		}
+
+		// parsing is deterministic
+		pointer2, tags2, err2 := parse(fset, c, "+test")
+
+		if err2 != nil || pointer2 != pointer || !tagsEqual(tags, tags2) {
+			t.Fatalf("parsing %q again should give the same result", comment)
+		}
+
+		// formatting round-trips, and is stable
+		formatted := FormatDirective("+test", pointer, tags)
+		f := fset.AddFile("formatted.go", -1, len(formatted)+1)
+		pointer3, tags3, err3 := parse(fset, &ast.Comment{Slash: f.Pos(0), Text: formatted}, "+test")
+
+		if err3 != nil {
+			t.Fatalf("formatted %q as %q, which fails to parse: %v", comment, formatted, err3)
+		}
+
+		// tags are formatted in order of name
+		sorted := append(TagSlice(nil), tags...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Name < sorted[j].Name
+		})
+
+		if pointer3 != pointer || !tagsEqual(tags3, sorted) || !typeParametersEqual(tags3, sorted) {
+			t.Fatalf("formatted %q as %q, which parses differently: %v, then %v", comment, formatted, sorted, tags3)
+		}
+
+		if again := FormatDirective("+test", pointer3, tags3); again != formatted {
+			t.Fatalf("formatted %q as %q, then as %q", comment, formatted, again)
+		}
+	})
+}
+
+// typeParametersEqual compares the type parameters of tag values as written, before they are evaluated
+func typeParametersEqual(tags, other TagSlice) bool {
+	for i := range tags {
+		for j, v := range tags[i].Values {
+			ov := other[i].Values[j]
+
+			if len(v.typeParameters) != len(ov.typeParameters) {
+				return false
+			}
+
+			for k := range v.typeParameters {
+				if v.typeParameters[k].val != ov.typeParameters[k].val {
+					return false
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+func TestParseGoStyle(t *testing.T) {
 	tests := []parseTest{
-		{`// +test foo`, false, TagSlice{
+		{`//test:foo`, false, TagSlice{
 			{"foo", []TagValue{}, false},
 		}, true},
-		{`// +test foo bar`, false, TagSlice{
-			{"foo", []TagValue{}, false},
-			{"bar", []TagValue{}, false},
-		}, true},
-		{`// +test foo:"bar,Baz"`, false, TagSlice{
-			{"foo", []TagValue{
-				{"bar", nil, nil},
-				{"Baz", nil, nil},
-			}, false},
-		}, true},
-		{`// +test * foo:"bar,Baz"`, true, TagSlice{
-			{"foo", []TagValue{
-				{"bar", nil, nil},
-				{"Baz", nil, nil},
-			}, false},
-		}, true},
-		{`// +test foo:"bar,Baz" qux:"stuff"`, false, TagSlice{
-			{"foo", []TagValue{
-				{"bar", nil, nil},
-				{"Baz", nil, nil},
-			}, false},
-			{"qux", []TagValue{
-				{"stuff", nil, nil},
-			}, false},
-		}, true},
-		{`// +test foo:"-bar,Baz"`, false, TagSlice{
-			{"foo", []TagValue{
-				{"bar", nil, nil},
-				{"Baz", nil, nil},
-			}, true},
-		}, true},
-		{`// +test foo:"bar  ,Baz "  `, false, TagSlice{
-			{"foo", []TagValue{
-				{"bar", nil, nil},
-				{"Baz", nil, nil},
-			}, false},
-		}, true},
-		{`// +test foo:"bar,Baz[qaz], qux"`, false, TagSlice{
-			{"foo", []TagValue{
-				{"bar", nil, nil},
-				{"Baz", nil, []item{{val: "qaz"}}},
-				{"qux", nil, nil},
-			}, false},
-		}, true},
-		{`// +test foo:"bar,Baz[[]qaz]"`, false, TagSlice{
-			{"foo", []TagValue{
-				{"bar", nil, nil},
-				{"Baz", nil, []item{{val: "[]qaz"}}},
-			}, false},
-		}, true},
-		{`// +test foo:"bar,Baz[qaz,hey]" qux:"stuff"`, false, TagSlice{
+		{`//test:* foo:"bar,-Baz" qux`, true, TagSlice{
 			{"foo", []TagValue{
-				{"bar", nil, nil},
-				{"Baz", nil, []item{{val: "qaz"}, {val: "hey"}}},
-			}, false},
-			{"qux", []TagValue{
-				{"stuff", nil, nil},
+				{Name: "bar"},
+				{Name: "Baz", Negated: true},
 			}, false},
+			{"qux", []TagValue{}, false},
 		}, true},
-		{`// +test foo:"Baz[qaz],yo[dude]" qux:"stuff[things]"`, false, TagSlice{
-			{"foo", []TagValue{
-				{"Baz", nil, []item{{val: "qaz"}}},
-				{"yo", nil, []item{{val: "dude"}}},
-			}, false},
-			{"qux", []TagValue{
-				{"stuff", nil, []item{{val: "things"}}},
-			}, false},
-		}, true},
-		{`// +test foo:"bar,Baz`, false, nil, false},
-		{`// +test foo:"bar,-Baz"`, false, nil, false},
-		{`// +test foo:"bar,Baz-"`, false, nil, false},
-		{`// +test foo:bar,Baz" qux:"stuff"`, false, nil, false},
-		{`// +test foo"bar,Baz" qux:"stuff"`, false, nil, false},
-		{`// +test foo:"bar,Baz" 8qux:"stuff"`, false, nil, false},
-		{`// +test fo^o:"bar,Baz" qux:"stuff"`, false, nil, false},
-		{`// +test foo:"bar,Ba|z" qux:"stuff"`, false, nil, false},
-		{`// +test foo:"bar,Baz" qux:"stuff`, false, nil, false},
-		{`// +test *foo:"bar,Baz" qux:"stuff"`, false, nil, false},
-		{`// +test foo:"bar,Baz" * qux:"stuff"`, false, nil, false},
-		{`// +test * foo:"bar,Baz" * qux:"stuff"`, false, nil, false},
-		{`// +test foo:"bar,Baz[foo"`, false, nil, false},
-		{`// +test foo:"bar,Baz[foo]]"`, false, nil, false},
-		{`// +test foo:"bar,Baz[[]foo"`, false, nil, false},
-		{`// +test foof:"bar,Baz" foof:"qux"`, false, nil, false},
+		{`//test:`, false, nil, true},
+		{`//test:*foo`, false, nil, false},
+		{`//test: foo:bar`, false, nil, false},
 	}
 
 	fset := token.NewFileSet()
 
 	for i, test := range tests {
+		file := fset.AddFile("test.go", -1, len(test.comment)+1)
 		c := &ast.Comment{
-			Text: test.comment,
+			Slash: file.Pos(0),
+			Text:  test.comment,
 		}
-		pointer, tags, err := parse(fset, c, "+test")
 
-		if test.valid != (err == nil) {
-			t.Errorf("[test %v] valid should have been %v for: %s\n%s", i, test.valid, test.comment, err)
+		pointer, tags, err := parse(fset, c, "test:")
+
+		if valid := err == nil; valid != test.valid {
+			t.Errorf("[test %v] valid should have been %v for %s, got %v", i, test.valid, test.comment, err)
+			continue
 		}
 
-		if pointer != test.pointer {
-			t.Errorf("[test %v] pointer should have been %v for: \n%s", i, bool(test.pointer), test.comment)
+		if pointer != test.pointer || !tagsEqual(tags, test.tags) {
+			t.Errorf("[test %v] should have been %v %v, got %v %v", i, test.pointer, test.tags, pointer, tags)
 		}
+	}
+}
 
-		if !tagsEqual(tags, test.tags) {
-			t.Fatalf("[test %v] tags should have been \n%v, got \n%v", i, test.tags, tags)
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		comment string
+		columns []int
+	}{
+		{`// +test foo:"bar,Ba|z" 8qux:"stuff, things" * quux:"a-" ok:"fine"`, []int{22, 26, 46, 56}},
+		{`// +test * * foo:"bar,Baz[foo" qux:"stuff" foo`, []int{12, 30, 44}},
+		{`// +test foo:"-bar,--baz,qux[int]]" qux:"-thing,+"`, []int{21, 35, 49}},
+		{`// +test foo:bar qux:"stuff`, []int{15, 28}},
+	}
+
+	fset := token.NewFileSet()
+	f := fset.AddFile("dummy.go", -1, 1000)
+
+	for i, test := range tests {
+		c := &ast.Comment{
+			Slash: f.Pos(0),
+			Text:  test.comment,
+		}
+
+		_, _, err := parse(fset, c, "+test")
+
+		errs, ok := err.(ErrorList)
+
+		if !ok {
+			t.Errorf("[test %v] should have returned an ErrorList, got %v", i, err)
+			continue
+		}
+
+		if len(errs) != len(test.columns) {
+			t.Errorf("[test %v] should have found %v errors, found %v:\n%s", i, len(test.columns), len(errs), errs)
+			continue
+		}
+
+		for j, e := range errs {
+			if e.Pos.Filename != "dummy.go" || e.Pos.Column != test.columns[j] {
+				t.Errorf("[test %v] error %v should be at column %v, got %s", i, j, test.columns[j], e)
+			}
+		}
+	}
+}
+
+func TestParseGoroutines(t *testing.T) {
+	fset := token.NewFileSet()
+	before := runtime.NumGoroutine()
+
+	// parse returns early for other directives, abandoning the lexer
+	for i := 0; i < 100; i++ {
+		for _, c := range benchmarkComments {
+			parse(fset, &ast.Comment{Text: c}, "+test")
+		}
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("parsing should not start goroutines; had %v, now %v", before, after)
+	}
+}
+
+var benchmarkComments = []string{
+	`// +test foo`,
+	`// +test * foo:"bar,Baz" qux:"stuff"`,
+	`// +test foo:"-bar,Baz[qaz,hey]" qux:"stuff[things],Other[[]map[string]*Thing]"`,
+	`// +test foo:"bar,Ba|z" 8qux:"stuff"`, // errors
+	`// +notreal foo:"bar,Baz" qux:"stuff"`,
+}
+
+func BenchmarkLex(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, c := range benchmarkComments {
+			l := lex(c, "+test")
+			for item := l.nextItem(); item.typ != itemEOF; item = l.nextItem() {
+			}
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	fset := token.NewFileSet()
+
+	var comments []*ast.Comment
+	for _, c := range benchmarkComments {
+		comments = append(comments, &ast.Comment{Text: c})
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, c := range comments {
+			parse(fset, c, "+test")
 		}
 	}
 }
@@ -192,7 +554,7 @@ func tagsEqual(tags, other TagSlice) bool {
 			tv := t.Values[j]
 			ov := o.Values[j]
 
-			if tv.Name != ov.Name {
+			if tv.Name != ov.Name || tv.Negated != ov.Negated || tv.Added != ov.Added {
 				return false
 			}
 
@@ -213,7 +575,7 @@ func tagsEqual(tags, other TagSlice) bool {
 
 func TestGetTypes(t *testing.T) {
 	// app and dummy types are marked up with +test
-	pkgs, err := getPackages("+test", DefaultConfig)
+	pkgs, err := getPackages([]string{"+test"}, DefaultConfig)
 
 	if err != nil {
 		t.Error(err)
@@ -327,7 +689,7 @@ func TestGetTypes(t *testing.T) {
 		return !strings.HasPrefix(f.Name(), "dummy")
 	}
 
-	pkgs2, err2 := getPackages("+test", conf)
+	pkgs2, err2 := getPackages([]string{"+test"}, conf)
 
 	if err2 != nil {
 		t.Error(err2)
@@ -350,7 +712,7 @@ func TestGetTypes(t *testing.T) {
 	}
 
 	// no false positives
-	pkgs3, err3 := getPackages("+notreal", DefaultConfig)
+	pkgs3, err3 := getPackages([]string{"+notreal"}, DefaultConfig)
 
 	typs3 := pkgs3[0].Types
 
@@ -370,13 +732,391 @@ func TestGetTypes(t *testing.T) {
 		return f.Name() == "package.go"
 	}
 
-	_, err4 := getPackages("+test", conf4)
+	_, err4 := getPackages([]string{"+test"}, conf4)
 
 	if err4 == nil {
 		t.Error("should have been unable to evaluate types of incomplete package")
 	}
 }
 
+func TestGetTargets(t *testing.T) {
+	// dummy funcs, consts and vars are marked up with +test
+	pkgs, err := getPackages([]string{"+test"}, DefaultConfig)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets := pkgs[0].Targets
+
+	if len(targets) != 4 {
+		t.Fatalf("should have found the 4 marked-up targets, found %v", len(targets))
+	}
+
+	m := make(map[string]Target)
+	for _, v := range targets {
+		m[v.Name] = v
+	}
+
+	tests := []struct {
+		name    string
+		kind    TargetKind
+		objects int
+	}{
+		{"dummyFunc", FuncTarget, 1},
+		{"dummy.dummyMethod", FuncTarget, 1},
+		{"dummyConst1", ConstTarget, 2},
+		{"dummyVar1", VarTarget, 2},
+	}
+
+	for _, test := range tests {
+		target, found := m[test.name]
+
+		if !found {
+			t.Errorf("should have found the %s target", test.name)
+			continue
+		}
+
+		if target.Kind != test.kind {
+			t.Errorf("%s should be a %s, got %s", test.name, test.kind, target.Kind)
+		}
+
+		if len(target.Objects) != test.objects {
+			t.Errorf("%s should have %v objects, found %v", test.name, test.objects, len(target.Objects))
+		}
+
+		if len(target.Tags) != 1 || target.Tags[0].Name != "foo" {
+			t.Errorf("%s should have the foo tag, found %v", test.name, target.Tags)
+		}
+
+		if !target.test {
+			t.Errorf("%s is declared in a _test.go file", test.name)
+		}
+	}
+
+	if f := m["dummyFunc"].Func(); f == nil || f.Name() != "dummyFunc" {
+		t.Errorf("Func() should return the dummyFunc *types.Func, got %v", f)
+	}
+
+	if f := m["dummyConst1"].Func(); f != nil {
+		t.Errorf("Func() should return nil for a const, got %v", f)
+	}
+}
+
+func TestGetTaggedTargetsEmpty(t *testing.T) {
+	src := `package dummy
+
+// +test foo
+const ()
+
+// +test foo
+var ()
+
+// +test foo
+var (
+	// +test foo
+	x int
+)
+`
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "dummy.go", src, parser.ParseComments)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &ast.Package{
+		Name:  f.Name.Name,
+		Files: map[string]*ast.File{"dummy.go": f},
+	}
+
+	targets := getTaggedTargets(a, "+test")
+
+	if len(targets) != 1 || targets[0].name != "x" {
+		t.Errorf("should have found only the target x, found %v", targets)
+	}
+}
+
+func TestGetFields(t *testing.T) {
+	src := `package dummy
+
+type thing struct {
+	// +test foo:"bar"
+	Name string // +test qux
+	Age, Height int // +test foo:"-baz"
+	*embedded
+	unannotated bool // just a comment
+}
+
+type bad struct {
+	// +test foo
+	Name string // +test foo:"bar"
+}
+`
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "dummy.go", src, parser.ParseComments)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	fields, err := getFields(fset, st, "+test")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		name string
+		tags TagSlice
+	}{
+		{"Name", TagSlice{
+			{"foo", []TagValue{{Name: "bar"}}, false},
+			{"qux", nil, false},
+		}},
+		{"Age", TagSlice{
+			{"foo", []TagValue{{Name: "baz"}}, true},
+		}},
+		{"Height", TagSlice{
+			{"foo", []TagValue{{Name: "baz"}}, true},
+		}},
+		{"embedded", nil},
+		{"unannotated", nil},
+	}
+
+	if len(fields) != len(expected) {
+		t.Fatalf("should have found %v fields, found %v", len(expected), len(fields))
+	}
+
+	for i, e := range expected {
+		if fields[i].Name != e.name {
+			t.Errorf("[field %v] name should have been %q, got %q", i, e.name, fields[i].Name)
+		}
+
+		if !tagsEqual(fields[i].Tags, e.tags) {
+			t.Errorf("[field %v] tags should have been \n%v, got \n%v", i, e.tags, fields[i].Tags)
+		}
+	}
+
+	// duplicate tags across doc and line comment
+	st2 := f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	if _, err := getFields(fset, st2, "+test"); err == nil {
+		t.Error("duplicate tags on a field should be an error")
+	}
+}
+
+func TestAnnotationFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "typewriter")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	getTypes := func(content string) ([]Type, error) {
+		conf := &Config{
+			AnnotationFile: filepath.Join(dir, "typewriter.json"),
+		}
+
+		if err := ioutil.WriteFile(conf.AnnotationFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		pkgs, err := getPackages([]string{"+test"}, conf)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return pkgs[0].Types, nil
+	}
+
+	// merged with comments, and a type annotated only in the file
+	typs, err := getTypes(`{
+	"+notreal": {
+		"nothing": {}
+	},
+	"+test": {
+		"dummy3": {
+			"tags": {
+				"qux": "-thing"
+			}
+		},
+		"fooWriter": {
+			"pointer": true,
+			"tags": {
+				"foo": "bar[int],baz",
+				"qux": ""
+			}
+		}
+	}
+}`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := typeSliceToMap(typs)
+
+	expected := map[string]TagSlice{
+		"dummy3": {
+			{"foo", []TagValue{{Name: "bar"}}, false},
+			{"qux", []TagValue{{Name: "thing"}}, true},
+		},
+		"fooWriter": {
+			{"foo", []TagValue{{Name: "bar", TypeParameters: []Type{{Name: "int"}}}, {Name: "baz"}}, false},
+			{"qux", nil, false},
+		},
+	}
+
+	for name, tags := range expected {
+		typ, found := m[name]
+
+		if !found {
+			t.Errorf("should have found the %s type", name)
+			continue
+		}
+
+		if !tagsEqual(typ.Tags, tags) {
+			t.Errorf("tags of %s should have been \n%v, got \n%v", name, tags, typ.Tags)
+		}
+	}
+
+	if !m["fooWriter"].Pointer {
+		t.Errorf("fooWriter should have been annotated as a pointer")
+	}
+
+	// errors should point at the file, and at the comment if conflicting
+	errs := []struct {
+		content  string
+		contains []string
+	}{
+		{`{"+test": {"dummy3": {"tags": {"foo": "bar"}}}}`, []string{"typewriter.json:1:", "dummy_test.go:10:"}},
+		{`{"+test": {"dummy3": {"pointer": true}}}`, []string{"typewriter.json:1:", "dummy_test.go:10:"}},
+		{`{"+test": {"notreal": {}}}`, []string{"typewriter.json:1:12"}},
+		{"{\"+test\": {\n\"dummy\": {\"tags\": {\"qux\": \"bar,--baz\"}}}}", []string{"typewriter.json:2:"}},
+		{`{"+test": {"dummy": {"tags": {"qux": true}}}}`, []string{"typewriter.json:1:"}},
+		{`{"+test": {"dummy": {"tagz": {}}}}`, []string{"typewriter.json:1:"}},
+		{`{"+test": {"dummy": {"tags": {"qux": "a", "qux": "b"}}}}`, []string{"typewriter.json:1:"}},
+		{`{"+test": {"dummy": `, []string{"typewriter.json:1:"}},
+	}
+
+	for i, e := range errs {
+		_, err := getTypes(e.content)
+
+		if err == nil {
+			t.Errorf("[test %v] should have been an error for:\n%s", i, e.content)
+			continue
+		}
+
+		for _, c := range e.contains {
+			if !strings.Contains(err.Error(), c) {
+				t.Errorf("[test %v] error should contain %q, got %q", i, c, err)
+			}
+		}
+	}
+
+	// an invalid file is reported once, however many directives are bound
+	conf := &Config{
+		AnnotationFile: filepath.Join(dir, "typewriter.json"),
+	}
+
+	if err := ioutil.WriteFile(conf.AnnotationFile, []byte(`{"+test": {"dummy": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = getPackages([]string{"+test", "other:"}, conf)
+
+	if list, ok := err.(ErrorList); !ok || len(list) != 1 {
+		t.Errorf("should have reported the annotation file once, got %v", err)
+	}
+
+	// a file which leaves out the pointer doesn't conflict with a pointer comment
+	if err := ioutil.WriteFile(conf.AnnotationFile, []byte(`{"+test": {"Thing": {"tags": {"set": ""}}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	f := fset.AddFile("a.go", -1, 100)
+
+	fileAnnotations, err := readAnnotationFile(fset, conf.AnnotationFile, []string{"+test"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	an, err := newCommentAnnotation(fset, &ast.Comment{Slash: f.Pos(0), Text: `// +test * slice:"Where"`}, "+test")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := an.merge(fset, "Thing", fileAnnotations["+test"]["Thing"]); err != nil {
+		t.Errorf("a file without a pointer declaration should not conflict, got %v", err)
+	}
+
+	if !an.pointer || len(an.tags) != 2 {
+		t.Errorf("should have merged into a pointer with 2 tags, got %v %v", an.pointer, an.tags)
+	}
+}
+
+func TestGetPackagesErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "typewriter")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	conf := &Config{
+		AnnotationFile: filepath.Join(dir, "typewriter.json"),
+	}
+
+	// every error should be reported, not just the first
+	content := `{
+	"+test": {
+		"notreal": {},
+		"dummy3": {"tags": {"foo": "bar"}},
+		"dummy2": {"tags": {"qux": "thing[notreal]"}},
+		"alsonotreal": {}
+	}
+}`
+
+	if err := ioutil.WriteFile(conf.AnnotationFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = getPackages([]string{"+test"}, conf)
+
+	errs, ok := err.(ErrorList)
+
+	if !ok {
+		t.Fatalf("should have returned an ErrorList, got %v", err)
+	}
+
+	expected := []string{
+		"typewriter.json:3:",
+		"typewriter.json:4:", // foo conflicts with the comment on dummy3
+		"typewriter.json:5:",
+		"typewriter.json:6:",
+	}
+
+	if len(errs) != len(expected) {
+		t.Fatalf("should have found %v errors, found %v:\n%s", len(expected), len(errs), errs)
+	}
+
+	for i, e := range expected {
+		if !strings.Contains(errs[i].Error(), e) {
+			t.Errorf("error %v should contain %q, got %q", i, e, errs[i])
+		}
+	}
+}
+
 func typeSliceToMap(typs []Type) map[string]Type {
 	result := make(map[string]Type)
 	for _, v := range typs {
@@ -9,6 +9,7 @@
 package typewriter
 
 import (
+	"fmt"
 	"go/types"
 )
 
@@ -35,3 +36,96 @@ func isPointer(typ types.Type) Pointer {
 	_, ok := typ.Underlying().(*types.Pointer)
 	return Pointer(ok)
 }
+
+// kinds is a set of the kinds of a type's underlying type, cached on Type; see Constraint
+type kinds uint16
+
+const (
+	kindInteger kinds = 1 << iota
+	kindFloat
+	kindSigned
+	kindUnsigned
+	kindString
+	kindBoolean
+	kindStruct
+	kindSlice
+	kindMap
+	kindPointer
+	kindInterface
+	kindChannel
+	kindFunc
+)
+
+// String describes a single kind, for error messages
+func (k kinds) String() string {
+	switch k {
+	case kindInteger:
+		return "an integer"
+	case kindFloat:
+		return "a floating-point number"
+	case kindSigned:
+		return "a signed integer"
+	case kindUnsigned:
+		return "an unsigned integer"
+	case kindString:
+		return "a string"
+	case kindBoolean:
+		return "a boolean"
+	case kindStruct:
+		return "a struct"
+	case kindSlice:
+		return "a slice"
+	case kindMap:
+		return "a map"
+	case kindPointer:
+		return "a pointer"
+	case kindInterface:
+		return "an interface"
+	case kindChannel:
+		return "a channel"
+	case kindFunc:
+		return "a func"
+	}
+	return fmt.Sprintf("kinds(%d)", uint16(k))
+}
+
+func kindsOf(typ types.Type) kinds {
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		var k kinds
+		info := t.Info()
+		if info&types.IsInteger != 0 {
+			k |= kindInteger
+			if info&types.IsUnsigned != 0 {
+				k |= kindUnsigned
+			} else {
+				k |= kindSigned
+			}
+		}
+		if info&types.IsFloat != 0 {
+			k |= kindFloat
+		}
+		if info&types.IsString != 0 {
+			k |= kindString
+		}
+		if info&types.IsBoolean != 0 {
+			k |= kindBoolean
+		}
+		return k
+	case *types.Struct:
+		return kindStruct
+	case *types.Slice:
+		return kindSlice
+	case *types.Map:
+		return kindMap
+	case *types.Pointer:
+		return kindPointer
+	case *types.Interface:
+		return kindInterface
+	case *types.Chan:
+		return kindChannel
+	case *types.Signature:
+		return kindFunc
+	}
+	return 0
+}
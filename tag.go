@@ -2,13 +2,121 @@ package typewriter
 
 // +gen slice
 type Tag struct {
-	Name    string
-	Values  []TagValue
+	Name   string
+	Values []TagValue
+	// Negated is true when the values are a list of exclusions, written with a single leading minus, eg slice:"-Where,Count".
+	// Where values are individually prefixed, see TagValue; a list with a leading minus must then prefix every value.
 	Negated bool
 }
 
 type TagValue struct {
 	Name           string
 	TypeParameters []Type
+	// Negated is true for a value which is excluded from the typewriter's defaults, eg the -Count in slice:"Where,-Count"
+	Negated bool
+	// Added is true for a value which is included in addition to the typewriter's defaults, eg the +Shuffle in slice:"-Count,+Shuffle"
+	Added          bool
 	typeParameters []item
 }
+
+// Relative reports whether the values of the tag modify the typewriter's defaults, rather than replacing them.
+// This is the case when any value is prefixed with a minus or plus.
+func (t Tag) Relative() bool {
+	if t.Negated {
+		return true
+	}
+
+	for _, v := range t.Values {
+		if v.Negated || v.Added {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Resolve returns the effective values of the tag, given a typewriter's default values. A tag without values
+// resolves to the defaults. A tag whose values are Relative resolves to the defaults, less exclusions, plus inclusions,
+// in that order; otherwise the tag's values are returned as they are. For example, given defaults Where, Count and Any:
+//
+//	slice                    Where, Count, Any
+//	slice:"Where,Shuffle"    Where, Shuffle
+//	slice:"-Where,Count"     Any
+//	slice:"-Count,+Shuffle"  Where, Any, Shuffle
+//	slice:"Shuffle,-Any"     Where, Count, Shuffle
+func (t Tag) Resolve(defaults ...string) []TagValue {
+	if len(t.Values) == 0 && !t.Negated {
+		var result []TagValue
+		for _, d := range defaults {
+			result = append(result, TagValue{Name: d})
+		}
+		return result
+	}
+
+	if !t.Relative() {
+		return t.Values
+	}
+
+	excluded := make(map[string]bool)
+	for _, v := range t.Values {
+		if t.Negated || v.Negated {
+			excluded[v.Name] = true
+		}
+	}
+
+	var result []TagValue
+	included := make(map[string]bool)
+
+	for _, d := range defaults {
+		if !excluded[d] && !included[d] {
+			result = append(result, TagValue{Name: d})
+			included[d] = true
+		}
+	}
+
+	for _, v := range t.Values {
+		if t.Negated || v.Negated {
+			continue
+		}
+
+		// a value may carry type parameters, which a default does not
+		v.Negated, v.Added = false, false
+		if included[v.Name] {
+			for i := range result {
+				if result[i].Name == v.Name {
+					result[i] = v
+				}
+			}
+			continue
+		}
+
+		result = append(result, v)
+		included[v.Name] = true
+	}
+
+	return result
+}
+
+// Defaulter is an optional interface for typewriters which have a default set of tag values, such that
+// users may write a tag without values, or with values relative to the defaults. See Tag.Resolve.
+type Defaulter interface {
+	Interface
+	Defaults() []string
+}
+
+// ResolveTag returns the effective values of the typewriter's tag on a Type, and whether the tag was found.
+// If the typewriter implements Defaulter, values are resolved against its defaults.
+func ResolveTag(tw Interface, t Type) ([]TagValue, bool) {
+	tag, found := t.FindTag(tw)
+
+	if !found {
+		return nil, false
+	}
+
+	var defaults []string
+	if d, ok := tw.(Defaulter); ok {
+		defaults = d.Defaults()
+	}
+
+	return tag.Resolve(defaults...), true
+}
@@ -0,0 +1,94 @@
+package typewriter
+
+import (
+	"go/ast"
+	"go/token"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	defaults := []string{"Where", "Count", "Any"}
+
+	tests := []struct {
+		comment  string
+		relative bool
+		resolved string
+	}{
+		{`// +test slice`, false, "Where,Count,Any"},
+		{`// +test slice:"Where,Shuffle"`, false, "Where,Shuffle"},
+		{`// +test slice:"-Where,Count"`, true, "Any"},
+		{`// +test slice:"-"`, true, "Where,Count,Any"},
+		{`// +test slice:"-Count,+Shuffle"`, true, "Where,Any,Shuffle"},
+		{`// +test slice:"Shuffle,-Any"`, true, "Where,Count,Shuffle"},
+		{`// +test slice:"-Where,-Count"`, true, "Any"},
+		{`// +test slice:"+Count[int],-Where"`, true, "Count[int],Any"},
+		{`// +test slice:"-Where,+Count,-Any"`, true, "Count"},
+	}
+
+	for i, test := range tests {
+		fset := token.NewFileSet()
+		file := fset.AddFile("test.go", -1, len(test.comment)+1)
+
+		_, tags, err := parse(fset, &ast.Comment{Slash: file.Pos(0), Text: test.comment}, "+test")
+
+		if err != nil {
+			t.Errorf("[test %v] %v", i, err)
+			continue
+		}
+
+		if relative := tags[0].Relative(); relative != test.relative {
+			t.Errorf("[test %v] relative should be %v for %s", i, test.relative, test.comment)
+		}
+
+		var resolved []string
+		for _, v := range tags[0].Resolve(defaults...) {
+			if v.Negated || v.Added {
+				t.Errorf("[test %v] resolved values should not be prefixed, got %s", i, v)
+			}
+			resolved = append(resolved, v.String())
+		}
+
+		if s := strings.Join(resolved, ","); s != test.resolved {
+			t.Errorf("[test %v] %s should resolve to %s, got %s", i, test.comment, test.resolved, s)
+		}
+	}
+
+	// without the other prefixes, Count would be excluded, as above; an unprefixed value is ambiguous
+	comment := `// +test slice:"-Where,Count,-Any"`
+	fset := token.NewFileSet()
+	file := fset.AddFile("test.go", -1, len(comment)+1)
+
+	_, _, err := parse(fset, &ast.Comment{Slash: file.Pos(0), Text: comment}, "+test")
+
+	if errs, ok := err.(ErrorList); !ok || len(errs) != 1 || errs[0].Code != CodeSyntax || errs[0].Pos.Column != 24 {
+		t.Errorf("%s should be a syntax error at Count, got %v", comment, err)
+	}
+}
+
+type defaulter struct{}
+
+func (defaulter) Name() string                    { return "slice" }
+func (defaulter) Imports(t Type) []ImportSpec     { return nil }
+func (defaulter) Write(w io.Writer, t Type) error { return nil }
+func (defaulter) Defaults() []string              { return []string{"Where", "Count"} }
+
+func TestResolveTag(t *testing.T) {
+	typ := Type{
+		Name: "Thing",
+		Tags: TagSlice{
+			{Name: "slice", Values: []TagValue{{Name: "Where", Negated: true}, {Name: "Shuffle", Added: true}}},
+		},
+	}
+
+	vals, found := ResolveTag(defaulter{}, typ)
+
+	if !found || len(vals) != 2 || vals[0].Name != "Count" || vals[1].Name != "Shuffle" {
+		t.Errorf("slice tag should resolve to Count,Shuffle, got %v", vals)
+	}
+
+	if _, found := ResolveTag(defaulter{}, Type{Name: "Other"}); found {
+		t.Errorf("tag should not be found on Other")
+	}
+}
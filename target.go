@@ -0,0 +1,65 @@
+package typewriter
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// Target is an annotated declaration other than a type: a func or method, or a const or var declaration.
+// Typewriters which implement TargetWriter are handed the Targets of a package.
+type Target struct {
+	Kind TargetKind
+	// Name of the func, or of the first const or var in the declaration. Methods are named Receiver.Method.
+	Name string
+	Tags TagSlice
+	// Directive which annotated the declaration, eg +gen
+	Directive string
+	// The source declaration, for documentation and error reporting
+	Position token.Position // of the first name declared, including the name of its file
+	Node     ast.Node       // the *ast.FuncDecl, or the *ast.GenDecl or *ast.ValueSpec which was annotated
+	File     *ast.File      // which contains the declaration
+	Doc      string         // text of the declaration's doc comment, less directives
+	// The declared func, or each const or var, in source order.
+	// A directive on a parenthesized const or var block applies to every name in the block.
+	Objects []types.Object
+	test    test
+}
+
+// TargetKind identifies the sort of declaration a Target was annotated on.
+type TargetKind int
+
+const (
+	FuncTarget TargetKind = iota
+	ConstTarget
+	VarTarget
+)
+
+func (k TargetKind) String() string {
+	switch k {
+	case FuncTarget:
+		return "func"
+	case ConstTarget:
+		return "const"
+	case VarTarget:
+		return "var"
+	}
+	return "unknown"
+}
+
+func (t Target) String() string {
+	return t.Name
+}
+
+// Func returns the annotated func or method, or nil if the Target is a const or var.
+func (t Target) Func() *types.Func {
+	if t.Kind != FuncTarget || len(t.Objects) == 0 {
+		return nil
+	}
+	f, _ := t.Objects[0].(*types.Func)
+	return f
+}
+
+func (t Target) FindTag(tw Interface) (Tag, bool) {
+	return findTag(t.Tags, tw.Name())
+}
@@ -1,7 +1,7 @@
 package typewriter
 
 import (
-	"fmt"
+	"go/token"
 	"strings"
 
 	"text/template"
@@ -25,24 +25,39 @@ func (tmpl *Template) Parse() (*template.Template, error) {
 // TryTypeAndValue verifies that a given Type and TagValue satisfy a Template's type constraints.
 func (tmpl *Template) TryTypeAndValue(t Type, v TagValue) error {
 	if err := tmpl.TypeConstraint.TryType(t); err != nil {
-		return fmt.Errorf("cannot apply %s to %s: %s", v.Name, t, err)
+		return templateError(t.Position, constraintCode(err, CodeTypeConstraint), "", v.Name, "cannot apply %s to %s: %s", v.Name, t, err)
 	}
 
 	if len(tmpl.TypeParameterConstraints) != len(v.TypeParameters) {
-		return fmt.Errorf("%s requires %d type parameters", v.Name, len(v.TypeParameters))
+		return templateError(t.Position, CodeTypeParameterCount, "", v.Name, "%s requires %d type parameters", v.Name, len(tmpl.TypeParameterConstraints))
 	}
 
 	for i := range v.TypeParameters {
 		c := tmpl.TypeParameterConstraints[i]
 		tp := v.TypeParameters[i]
 		if err := c.TryType(tp); err != nil {
-			return fmt.Errorf("cannot apply %v on %s: %s", v, t, err)
+			return templateError(t.Position, constraintCode(err, CodeTypeParameterConstraint), "", v.Name, "cannot apply %v on %s: %s", v, t, err)
 		}
 	}
 
 	return nil
 }
 
+// constraintCode returns CodeInvalidConstraint if the error is in the constraint itself, otherwise code
+func constraintCode(err error, code Code) Code {
+	if _, ok := err.(invalidConstraint); ok {
+		return CodeInvalidConstraint
+	}
+	return code
+}
+
+// templateError describes a tag or tag value which can't be applied to a type, positioned at the type's declaration
+func templateError(pos token.Position, code Code, tag, value string, format string, args ...interface{}) *Error {
+	err := newError(pos, code, format, args...)
+	err.Tag, err.Value = tag, value
+	return err
+}
+
 // Funcs assigns non standard functions used in the template
 func (ts TemplateSlice) Funcs(FuncMap map[string]interface{}) {
 	for _, tmpl := range ts {
@@ -58,7 +73,7 @@ func (ts TemplateSlice) ByTag(t Type, tag Tag) (*template.Template, error) {
 	})
 
 	if len(candidates) == 0 {
-		err := fmt.Errorf("could not find template for %q", tag.Name)
+		err := templateError(t.Position, CodeUnknownTag, tag.Name, "", "could not find template for %q", tag.Name)
 		return nil, err
 	}
 
@@ -71,7 +86,8 @@ func (ts TemplateSlice) ByTag(t Type, tag Tag) (*template.Template, error) {
 	}
 
 	// send back the first error message; not great but OK most of the time
-	return nil, candidates[0].TypeConstraint.TryType(t)
+	err := candidates[0].TypeConstraint.TryType(t)
+	return nil, templateError(t.Position, constraintCode(err, CodeTypeConstraint), tag.Name, "", "%s", err)
 }
 
 // ByTagValue attempts to locate a template which meets type constraints, and parses it.
@@ -84,7 +100,7 @@ func (ts TemplateSlice) ByTagValue(t Type, v TagValue) (*template.Template, erro
 	})
 
 	if len(candidates) == 0 {
-		err := fmt.Errorf("%s is unknown", v.Name)
+		err := templateError(t.Position, CodeUnknownTagValue, "", v.Name, "%s is unknown", v.Name)
 		return nil, err
 	}
 
@@ -267,6 +267,38 @@ func TestByTag(t *testing.T) {
 	if b.String() != slice4[2].Text { // "This should be found."
 		t.Error("should have picked the template which matches type constraints")
 	}
+
+	// by kind
+	slice5 := TemplateSlice{
+		{
+			Name:           "TestTag",
+			Text:           "Integer.",
+			TypeConstraint: Constraint{Integer: true},
+		},
+		{
+			Name:           "TestTag",
+			Text:           "Slice.",
+			TypeConstraint: Constraint{Slice: true},
+		},
+	}
+
+	typ5 := Type{
+		Name:  "TestType",
+		kinds: kindSlice,
+	}
+
+	tmpl5, err5 := slice5.ByTag(typ5, tag4)
+
+	if err5 != nil {
+		t.Error(err5)
+	}
+
+	b.Reset()
+	tmpl5.Execute(&b, nil)
+
+	if b.String() != slice5[1].Text {
+		t.Error("should have picked the template which matches the kind of the type")
+	}
 }
 
 func TestByTagValue(t *testing.T) {
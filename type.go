@@ -2,9 +2,9 @@ package typewriter
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
 
+	"go/ast"
+	"go/token"
 	"go/types"
 )
 
@@ -12,8 +12,21 @@ type Type struct {
 	Pointer                      Pointer
 	Name                         string
 	Tags                         TagSlice
+	TypeParams                   []TypeParam    // of a generic type, eg Tree[T any]; see also Instantiate
+	Directive                    string         // which annotated the type, eg +gen; see Config.NewAppDirectives
+	Alias                        bool           // declared as an alias, eg type Users = []pb.User; see CanDeclareMethods
+	Position                     token.Position // of the source declaration, including the name of its file
+	Spec                         *ast.TypeSpec  // the source declaration; for a type from another package, the placeholder (see the target tag)
+	File                         *ast.File      // which contains the declaration
+	Doc                          string         // text of the declaration's doc comment, less directives
 	comparable, numeric, ordered bool
+	kinds                        kinds
 	test                         test
+	fields                       []Field
+	receiver                     string   // why methods may not be declared on the type, if they may not
+	source                       *Package // which evaluated the type, for resolving constraints
+	// the imports of the file being written, see Qualifier
+	imports *ImportPlan
 	types.Type
 }
 
@@ -28,29 +41,106 @@ func (t test) String() string {
 }
 
 func (t Type) String() (result string) {
-	return fmt.Sprintf("%s%s", t.Pointer.String(), t.Name)
+	return fmt.Sprintf("%s%s%s", t.Pointer.String(), t.Name, t.TypeArgs())
 }
 
-// LongName provides a name that may be useful for generated names.
-// For example, map[string]Foo becomes MapStringFoo.
-func (t Type) LongName() string {
-	s := strings.Replace(t.String(), "[]", "Slice[]", -1) // hacktastic
+// foreign reports whether the type is declared in a package other than p, see the target tag
+func (t Type) foreign(p *Package) bool {
+	pkg := t.pkg()
+	return pkg != nil && pkg.Path() != p.Path()
+}
+
+// pkg returns the package in which the (possibly pointed-to) named type is declared, or nil
+func (t Type) pkg() *types.Package {
+	if n := t.named(); n != nil {
+		return n.Obj().Pkg()
+	}
+	return nil
+}
+
+// named returns the (possibly pointed-to) named type, or nil; aliases are resolved to the types for which they stand
+func (t Type) named() *types.Named {
+	typ := types.Unalias(t.Type)
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = types.Unalias(ptr.Elem())
+	}
+	n, _ := typ.(*types.Named)
+	return n
+}
 
-	r := regexp.MustCompile(`[\[\]{}*]`)
-	els := r.Split(s, -1)
+// CanDeclareMethods reports whether methods may be declared on an annotated type in the package which declares it.
+// They may not where the type is an alias of an unnamed type or of a type from another package, or where it stands in
+// for a type from another package (see the target tag). Typewriters might instead generate functions, or return
+// the error from CheckMethods.
+func (t Type) CanDeclareMethods() bool {
+	return t.receiver == ""
+}
 
-	var parts []string
+// CheckMethods returns an error explaining why methods may not be declared on the type, or nil if they may. See CanDeclareMethods.
+func (t Type) CheckMethods() error {
+	if t.receiver == "" {
+		return nil
+	}
+	return newError(t.Position, CodeInvalidReceiver, "cannot declare methods on %s: %s", t, t.receiver)
+}
 
-	for _, s := range els {
-		parts = append(parts, strings.Title(s))
+// receiverError explains why methods may not be declared on the type in package p, or returns "" if they may
+func receiverError(t Type, p *types.Package) string {
+	typ := t.Type
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
 	}
+	typ = types.Unalias(typ)
 
-	return strings.Join(parts, "")
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return fmt.Sprintf("it is an alias of %s, which is not a defined type", types.TypeString(typ, qualifier(p)))
+	}
+
+	obj := named.Obj()
+	if obj.Pkg() == nil || obj.Pkg().Path() != p.Path() {
+		if t.Alias || obj.Pkg() == nil {
+			return fmt.Sprintf("it is an alias of %s, which is not declared in this package", types.TypeString(named, qualifier(p)))
+		}
+		return fmt.Sprintf("it is declared in package %q", obj.Pkg().Path())
+	}
+
+	switch named.Underlying().(type) {
+	case *types.Pointer:
+		return "its underlying type is a pointer"
+	case *types.Interface:
+		return "its underlying type is an interface"
+	}
+
+	return ""
+}
+
+// qualifier qualifies types from packages other than p by package name, eg token.Position
+func qualifier(p *types.Package) types.Qualifier {
+	return func(other *types.Package) string {
+		if other.Path() == p.Path() {
+			return ""
+		}
+		return other.Name()
+	}
+}
+
+// Fields returns the fields of a struct type (or a pointer to one) in declaration order, followed by promoted fields.
+// Fields of an annotated type have the tags of their directives. It returns nil for other types.
+func (t Type) Fields() []Field {
+	if t.Type == nil {
+		return t.fields
+	}
+	return t.source.fields(t.Type, t.fields)
 }
 
 func (t Type) FindTag(tw Interface) (Tag, bool) {
-	for _, tag := range t.Tags {
-		if tag.Name == tw.Name() {
+	return findTag(t.Tags, tw.Name())
+}
+
+func findTag(tags TagSlice, name string) (Tag, bool) {
+	for _, tag := range tags {
+		if tag.Name == name {
 			return tag, true
 		}
 	}
@@ -0,0 +1,225 @@
+package typewriter
+
+import (
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// Expr returns the type as it would be written in the package which evaluated it, qualifying types from other
+// packages, eg map[string]token.Position. See Imports for the packages to which it refers. In a typewriter's
+// Write, types are qualified by the names under which the file being written imports them, see Qualifier.
+func (t Type) Expr() string {
+	if t.source == nil || t.source.Package == nil {
+		return t.RelativeTo(nil)
+	}
+	if t.imports != nil {
+		return t.typeString(t.imports.Qualifier(t.source.Package))
+	}
+	return t.RelativeTo(t.source.Package)
+}
+
+// Qualifier returns the name by which the file being written refers to the package of the path, eg template2 where
+// both text/template and html/template are imported. It is known within a typewriter's Write; otherwise, and for
+// packages which the file does not import, it is the name of the package, as it would be guessed from the path.
+func (t Type) Qualifier(path string) string {
+	if t.imports != nil {
+		if name := t.imports.Name(path); name != "" {
+			return name
+		}
+	}
+	return ImportSpec{Path: path}.name()
+}
+
+// withImports returns a copy of the type, and of the type parameters of its tags, with the imports of the file being written.
+// Type parameters are renamed as they are written in the file, eg time.Duration for the tm.Duration of slice:"GroupBy[tm.Duration]".
+func (t Type) withImports(plan *ImportPlan) Type {
+	tags := make(TagSlice, len(t.Tags))
+
+	for i, tag := range t.Tags {
+		values := make([]TagValue, len(tag.Values))
+
+		for j, v := range tag.Values {
+			params := make([]Type, len(v.TypeParameters))
+			for k, tp := range v.TypeParameters {
+				tp.imports = plan
+				if tp.Type != nil {
+					tp.Name = strings.TrimPrefix(tp.Expr(), Pointer(true).String())
+				}
+				params[k] = tp
+			}
+			if v.TypeParameters != nil {
+				v.TypeParameters = params
+			}
+			values[j] = v
+		}
+
+		if tag.Values != nil {
+			tag.Values = values
+		}
+		tags[i] = tag
+	}
+
+	if t.Tags != nil {
+		t.Tags = tags
+	}
+	t.imports = plan
+
+	return t
+}
+
+// RelativeTo returns the type as it would be written in package pkg, qualifying types from other packages by name.
+// If pkg is nil, types are qualified by import path.
+func (t Type) RelativeTo(pkg *types.Package) string {
+	return t.typeString(relativeTo(pkg))
+}
+
+// typeString returns the type as written with the qualifier
+func (t Type) typeString(q types.Qualifier) string {
+	if t.Type == nil {
+		return t.String()
+	}
+
+	// a generic type is written with its type parameters as arguments, eg Tree[T]
+	if t.Generic() {
+		obj := t.named().Obj()
+		name := obj.Name()
+		if prefix := q(obj.Pkg()); prefix != "" {
+			name = prefix + "." + name
+		}
+		return t.Pointer.String() + name + t.TypeArgs()
+	}
+
+	return types.TypeString(t.Type, q)
+}
+
+// relativeTo qualifies types from packages other than pkg by name, or all types by path if pkg is nil
+func relativeTo(pkg *types.Package) types.Qualifier {
+	if pkg == nil {
+		return func(other *types.Package) string {
+			return other.Path()
+		}
+	}
+	return qualifier(pkg)
+}
+
+// Zero returns an expression for the zero value of the type, as it would be written in the package which evaluated
+// it, eg 0, "", nil or token.Position{}. A template might write: return {{.Zero}}, false
+func (t Type) Zero() string {
+	if t.Type == nil {
+		return "*new(" + t.String() + ")"
+	}
+
+	if _, ok := types.Unalias(t.Type).(*types.TypeParam); ok {
+		return "*new(" + t.Expr() + ")"
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+		return "nil" // unsafe.Pointer
+	case *types.Struct, *types.Array:
+		return t.Expr() + "{}"
+	}
+
+	return "nil"
+}
+
+// Imports returns the packages to which the type refers, other than the package which evaluated it, eg go/token
+// for map[string]token.Position. A typewriter might add them to its own imports; see Expr.
+func (t Type) Imports() []ImportSpec {
+	var result []ImportSpec
+	for _, pkg := range t.packages() {
+		result = append(result, ImportSpec{Path: pkg.Path()})
+	}
+	return result
+}
+
+// packages returns the packages to which the type refers, other than the package which evaluated it, ordered by path
+func (t Type) packages() []*types.Package {
+	if t.Type == nil {
+		return nil
+	}
+
+	pkgs := make(map[string]*types.Package)
+	referenced(t.Type, pkgs, make(map[types.Type]bool))
+
+	if t.source != nil && t.source.Package != nil {
+		delete(pkgs, t.source.Path())
+	}
+
+	var result []*types.Package
+	for _, pkg := range pkgs {
+		result = append(result, pkg)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path() < result[j].Path()
+	})
+
+	return result
+}
+
+// referenced adds the packages to which the type expression refers, by path
+func referenced(typ types.Type, pkgs map[string]*types.Package, visited map[types.Type]bool) {
+	if visited[typ] {
+		return
+	}
+	visited[typ] = true
+
+	add := func(obj types.Object, args *types.TypeList) {
+		if obj.Pkg() != nil {
+			pkgs[obj.Pkg().Path()] = obj.Pkg()
+		}
+		for i := 0; i < args.Len(); i++ {
+			referenced(args.At(i), pkgs, visited)
+		}
+	}
+
+	switch x := typ.(type) {
+	case *types.Named:
+		add(x.Obj(), x.TypeArgs())
+	case *types.Alias:
+		add(x.Obj(), x.TypeArgs())
+	case *types.Pointer:
+		referenced(x.Elem(), pkgs, visited)
+	case *types.Slice:
+		referenced(x.Elem(), pkgs, visited)
+	case *types.Array:
+		referenced(x.Elem(), pkgs, visited)
+	case *types.Chan:
+		referenced(x.Elem(), pkgs, visited)
+	case *types.Map:
+		referenced(x.Key(), pkgs, visited)
+		referenced(x.Elem(), pkgs, visited)
+	case *types.Struct:
+		for i := 0; i < x.NumFields(); i++ {
+			referenced(x.Field(i).Type(), pkgs, visited)
+		}
+	case *types.Tuple:
+		for i := 0; i < x.Len(); i++ {
+			referenced(x.At(i).Type(), pkgs, visited)
+		}
+	case *types.Signature:
+		referenced(x.Params(), pkgs, visited)
+		referenced(x.Results(), pkgs, visited)
+	case *types.Interface:
+		for i := 0; i < x.NumExplicitMethods(); i++ {
+			referenced(x.ExplicitMethod(i).Type(), pkgs, visited)
+		}
+		for i := 0; i < x.NumEmbeddeds(); i++ {
+			referenced(x.EmbeddedType(i), pkgs, visited)
+		}
+	case *types.Union:
+		for i := 0; i < x.Len(); i++ {
+			referenced(x.Term(i).Type(), pkgs, visited)
+		}
+	}
+}
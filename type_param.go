@@ -0,0 +1,113 @@
+package typewriter
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// TypeParam is a type parameter of a generic type, eg T in Tree[T any].
+type TypeParam struct {
+	Name string
+	// Constraint as it would be written in the type's package, eg any or comparable
+	Constraint string
+	Param      *types.TypeParam
+}
+
+func (tp TypeParam) String() string {
+	return tp.Name
+}
+
+func newTypeParams(list *types.TypeParamList, pkg *types.Package) []TypeParam {
+	var result []TypeParam
+
+	for i := 0; i < list.Len(); i++ {
+		tp := list.At(i)
+		result = append(result, TypeParam{
+			Name:       tp.Obj().Name(),
+			Constraint: types.TypeString(tp.Constraint(), qualifier(pkg)),
+			Param:      tp,
+		})
+	}
+
+	return result
+}
+
+// Generic reports whether the type has type parameters, ie it is an uninstantiated generic type.
+func (t Type) Generic() bool {
+	return len(t.TypeParams) > 0
+}
+
+// TypeParamsDecl returns the type parameter list of a generic type as declared, eg [T any, K comparable],
+// for use in declarations of generic types and funcs. It returns an empty string for non-generic types.
+func (t Type) TypeParamsDecl() string {
+	if !t.Generic() {
+		return ""
+	}
+
+	var params []string
+	for _, tp := range t.TypeParams {
+		params = append(params, tp.Name+" "+tp.Constraint)
+	}
+
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+// TypeArgs returns the type parameters of a generic type as arguments, eg [T, K], for use in method receivers.
+// It returns an empty string for non-generic types.
+func (t Type) TypeArgs() string {
+	if !t.Generic() {
+		return ""
+	}
+
+	var args []string
+	for _, tp := range t.TypeParams {
+		args = append(args, tp.Name)
+	}
+
+	return "[" + strings.Join(args, ", ") + "]"
+}
+
+// Instantiate returns the generic type instantiated with the given type arguments, eg Tree[int].
+// Type parameters of tag values, eg slice:"Where[int]", are a convenient source of type arguments.
+func (t Type) Instantiate(args ...Type) (Type, error) {
+	var result Type
+
+	if !t.Generic() {
+		return result, fmt.Errorf("%s is not a generic type", t)
+	}
+
+	if len(args) != len(t.TypeParams) {
+		return result, fmt.Errorf("%s requires %d type arguments, got %d", t, len(t.TypeParams), len(args))
+	}
+
+	typ := t.Type
+	if t.Pointer {
+		typ = typ.(*types.Pointer).Elem()
+	}
+
+	var targs []types.Type
+	var names []string
+	for _, a := range args {
+		if a.Type == nil {
+			return result, fmt.Errorf("type argument %s has not been evaluated", a)
+		}
+		targs = append(targs, a.Type)
+		names = append(names, a.String())
+	}
+
+	inst, err := types.Instantiate(nil, typ, targs, true)
+	if err != nil {
+		return result, fmt.Errorf("cannot instantiate %s: %s", t, err)
+	}
+
+	if t.Pointer {
+		inst = types.NewPointer(inst)
+	}
+
+	result = t.source.newType(inst, fmt.Sprintf("%s[%s]", t.Name, strings.Join(names, ", ")))
+	result.Tags = t.Tags
+	result.test = t.test
+
+	return result, nil
+}
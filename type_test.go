@@ -1,6 +1,12 @@
 package typewriter
 
-import "testing"
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
 
 func TestLongName(t *testing.T) {
 	tests := []struct {
@@ -11,6 +17,9 @@ func TestLongName(t *testing.T) {
 		{"map[Foo]Bar", "MapFooBar"},
 		{"[]map[Foo]Bar", "SliceMapFooBar"},
 		{"[]map[Foo]struct{}", "SliceMapFooStruct"},
+		{"map[string]*Foo", "MapStringPointerFoo"},
+		{"[]time.Time", "SliceTimeTime"},
+		{"chan [4]int", "ChanArray4Int"},
 	}
 
 	for _, test := range tests {
@@ -24,3 +33,299 @@ func TestLongName(t *testing.T) {
 	}
 
 }
+
+func TestLongNameEval(t *testing.T) {
+	p, _ := testPackage(t, `package long
+
+import (
+	"go/token"
+	"unsafe"
+)
+
+type Foo struct{}
+
+type foo int
+
+type Bar struct{}
+
+type FooBar struct{}
+
+type Pair[K comparable, V any] struct{}
+
+type (
+	Position   = token.Position
+	Positions  = []token.Position
+	Unsafe     = unsafe.Pointer
+	Handler    = func(string, ...int) (bool, error)
+	Anonymous  = struct{ X, y int; Foo }
+	Stringer   = interface{ String() string }
+	Number     interface{ ~int | float64 }
+)
+`)
+
+	tests := []struct {
+		input, expected string
+	}{
+		{"Foo", "Foo"},
+		{"*Foo", "Foo"},
+		{"[]Foo", "SliceFoo"},
+		{"[]*Foo", "SlicePointerFoo"},
+		{"[4]Foo", "Array4Foo"},
+		{"map[string]Foo", "MapString_Foo"},
+		{"map[string]*Foo", "MapString_PointerFoo"},
+		{"map[*string]Foo", "MapPointerString_Foo"},
+		{"chan Foo", "ChanFoo"},
+		{"<-chan Foo", "ReceiveChanFoo"},
+		{"chan<- Foo", "SendChanFoo"},
+		{"func()", "Func"},
+		{"func(Foo) error", "FuncFooReturnsError"},
+		{"func(Foo, error)", "FuncFoo_Error"},
+		{"struct{}", "Struct"},
+		{"interface{}", "Interface"},
+		{"Pair[string, *Foo]", "PairString_PointerFoo"},
+		{"Pair[Foo, []int]", "PairFoo_SliceInt"},
+		{"func(func() int)", "FuncFuncReturnsInt"},
+		{"func(func()) int", "FuncFuncEndReturnsInt"},
+		{"func(...func())", "FuncSliceFuncEndVariadic"},
+		{"func(struct{}, int)", "FuncStructEnd_Int"},
+		{"func(interface{ String() string }, int)", "FuncInterfaceStringEnd_Int"},
+		{"map[struct{}]int", "MapStructEnd_Int"},
+		{"Pair[struct{}, int]", "PairStructEnd_Int"},
+		{"Pair[int, func()]", "PairInt_Func"},
+		{"[]func([]int)", "SliceFuncSliceInt"},
+		{"[]func() []int", "SliceFuncReturnsSliceInt"},
+	}
+
+	// aliases are named for what they stand for
+	aliases := []struct {
+		input, expected string
+	}{
+		{"Position", "TokenPosition"},
+		{"Positions", "SliceTokenPosition"},
+		{"Unsafe", "UnsafePointer"},
+		{"Handler", "FuncString_SliceIntVariadicReturnsBool_Error"},
+		{"Anonymous", "StructXInt_YInt_Foo"},
+		{"Stringer", "InterfaceString"},
+	}
+
+	names := make(map[string]string)
+
+	check := func(typ Type, input, expected string) {
+		name := typ.LongName()
+
+		if name != expected {
+			t.Errorf("%s: expected %q, got %q", input, expected, name)
+		}
+
+		if !token.IsIdentifier(name) {
+			t.Errorf("%s: %q is not a valid identifier", input, name)
+		}
+
+		if other, ok := names[name]; ok && other != strings.TrimPrefix(input, "*") {
+			t.Errorf("%s: %q collides with %s", input, name, other)
+		}
+		names[name] = strings.TrimPrefix(input, "*")
+	}
+
+	for _, test := range tests {
+		typ, err := p.Eval(test.input)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check(typ, test.input, test.expected)
+	}
+
+	for _, test := range aliases {
+		typ, err := p.Eval(test.input)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check(p.typeOf(types.Unalias(typ.Type)), test.input, test.expected)
+	}
+
+	number, _ := p.Eval("Number")
+	check(p.typeOf(number.Underlying()), "Number", "InterfaceTildeIntOrFloat64")
+
+	// as ever, the first letter of a declared name is upper-cased
+	if typ, _ := p.Eval("[]foo"); typ.LongName() != "SliceFoo" {
+		t.Errorf("[]foo: expected %q, got %q", "SliceFoo", typ.LongName())
+	}
+
+	// lists of every kind, of declared and structural types, which differ only by where their parts begin and end
+	atoms := []string{"int", "Foo", "Bar", "FooBar", "*Foo", "[]Bar", "func()", "func() int", "func(int)", "struct{}", "map[Foo]Bar"}
+
+	var inputs []string
+	for _, a := range atoms {
+		inputs = append(inputs, "func("+a+")", "func() "+a, "struct{ F "+a+" }", "[]"+a)
+		for _, b := range atoms {
+			inputs = append(inputs,
+				"func("+a+", "+b+")",
+				"func("+a+") "+b,
+				"func(...func("+a+")) "+b,
+				"struct{ F "+a+"; G "+b+" }",
+				"map["+a+"]"+b,
+				"Pair["+a+", "+b+"]",
+			)
+		}
+	}
+
+	evaluated := make(map[string]Type)
+
+	for _, input := range inputs {
+		typ, err := p.Eval(input)
+
+		if err != nil {
+			continue // eg a map key which is not comparable
+		}
+
+		name := typ.LongName()
+
+		if !token.IsIdentifier(name) {
+			t.Errorf("%s: %q is not a valid identifier", input, name)
+		}
+
+		if other, ok := evaluated[name]; ok && !types.Identical(other.Type, typ.Type) {
+			t.Errorf("%s: %q collides with %s", input, name, other)
+		}
+		evaluated[name] = typ
+	}
+}
+
+func TestExprAndZero(t *testing.T) {
+	p, _ := testPackage(t, `package expr
+
+import (
+	"go/token"
+	"io"
+)
+
+type Value int
+
+type Thing struct{}
+
+type Tree[T any] struct {
+	Root T
+}
+
+type (
+	Positions   [2]token.Position
+	Files       = map[token.Pos][]*Thing
+	Trees       = Tree[token.Position]
+	ReadFunc    = func(io.Reader) (*token.File, error)
+	Writer      = interface{ Write(*token.File) }
+	Anonymous   = struct{ r io.Reader }
+	Handlers    map[string]func(io.Writer)
+)
+`)
+
+	tests := []struct {
+		typ, expr, zero string
+		imports         []string
+	}{
+		{"int", "int", "0", nil},
+		{"Value", "Value", "0", nil},
+		{"string", "string", `""`, nil},
+		{"bool", "bool", "false", nil},
+		{"Thing", "Thing", "Thing{}", nil},
+		{"*Thing", "*Thing", "nil", nil},
+		{"Positions", "Positions", "Positions{}", nil},
+		{"[]Positions", "[]Positions", "nil", nil},
+		{"Files", "Files", "nil", nil},
+		{"Trees", "Trees", "Trees{}", nil},
+		{"ReadFunc", "ReadFunc", "nil", nil},
+		{"Writer", "Writer", "nil", nil},
+		{"Anonymous", "Anonymous", "Anonymous{}", nil},
+		{"Handlers", "Handlers", "nil", nil},
+	}
+
+	for i, test := range tests {
+		typ, err := p.Eval(test.typ)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if typ.Expr() != test.expr {
+			t.Errorf("[test %v] expression should be %q, got %q", i, test.expr, typ.Expr())
+		}
+
+		if typ.Zero() != test.zero {
+			t.Errorf("[test %v] zero value should be %q, got %q", i, test.zero, typ.Zero())
+		}
+
+		var imports []string
+		for _, imp := range typ.Imports() {
+			imports = append(imports, imp.Path)
+		}
+
+		if strings.Join(imports, ",") != strings.Join(test.imports, ",") {
+			t.Errorf("[test %v] imports should be %v, got %v", i, test.imports, imports)
+		}
+	}
+
+	// the types for which the aliases stand refer to other packages
+	aliases := map[string]string{
+		"Files":     "go/token",
+		"Trees":     "go/token",
+		"ReadFunc":  "go/token,io",
+		"Writer":    "go/token",
+		"Anonymous": "io",
+	}
+
+	for name, expected := range aliases {
+		typ, _ := p.Eval(name)
+		typ = p.typeOf(types.Unalias(typ.Type))
+
+		var imports []string
+		for _, imp := range typ.Imports() {
+			imports = append(imports, imp.Path)
+		}
+
+		if strings.Join(imports, ",") != expected {
+			t.Errorf("imports of %s should be %v, got %v", typ.Expr(), expected, imports)
+		}
+	}
+
+	files, _ := p.Eval("Files")
+	files = p.typeOf(types.Unalias(files.Type))
+
+	if files.Expr() != "map[token.Pos][]*Thing" {
+		t.Errorf("expression should be map[token.Pos][]*Thing, got %q", files.Expr())
+	}
+
+	if s := files.RelativeTo(types.NewPackage("go/token", "token")); s != "map[Pos][]*expr.Thing" {
+		t.Errorf("expression relative to go/token should be map[Pos][]*expr.Thing, got %q", s)
+	}
+
+	if s := files.RelativeTo(nil); s != "map[go/token.Pos][]*expr.Thing" {
+		t.Errorf("expression relative to no package should be qualified by path, got %q", s)
+	}
+
+	trees, _ := p.Eval("Trees")
+	trees = p.typeOf(types.Unalias(trees.Type))
+
+	if trees.Expr() != "Tree[token.Position]" || trees.Zero() != "Tree[token.Position]{}" {
+		t.Errorf("expression should be Tree[token.Position], got %q and %q", trees.Expr(), trees.Zero())
+	}
+
+	// generic
+	tree, err := p.evalTypeSpec(false, &ast.TypeSpec{Name: ast.NewIdent("Tree"), TypeParams: &ast.FieldList{}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tree.Expr() != "Tree[T]" || tree.Zero() != "Tree[T]{}" {
+		t.Errorf("generic type should be written with its type parameters, got %q and %q", tree.Expr(), tree.Zero())
+	}
+
+	root := tree.Fields()[0].Type
+
+	if root.Zero() != "*new(T)" {
+		t.Errorf("the zero value of a type parameter should be *new(T), got %q", root.Zero())
+	}
+}